@@ -0,0 +1,213 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// values of the upload_to param of out.
+const (
+	uploadToProjectUpload  = "project_upload"
+	uploadToGenericPackage = "generic_package"
+	uploadToS3             = "s3"
+)
+
+// packageNamePattern matches the names and versions GitLab accepts for
+// generic packages.
+var packageNamePattern = regexp.MustCompile(`^[A-Za-z0-9._+-]+$`)
+
+// assetStore stores the asset files of a release and describes the links to
+// them.
+type assetStore interface {
+	// operation is what the auth type must be allowed to store files, if
+	// they are stored on GitLab.
+	operation() string
+	// linkType is the type of the links to the stored files.
+	linkType() gitlab.LinkTypeValue
+	// directAssetPath is the direct asset path given to the link to the
+	// file when the asset has none, if any.
+	directAssetPath(file string) string
+	store(ctx context.Context, file string) (ReleaseLinkSpec, error)
+}
+
+// newAssetStore returns the store selected by upload_to, with the version of
+// generic packages taken from the tag.
+func newAssetStore(client GitLab, source Source, params OutParams, tag string) (assetStore, error) {
+	switch params.UploadTo {
+	case "", uploadToProjectUpload:
+		return &projectUploads{gitlab: client}, nil
+	case uploadToGenericPackage:
+		if params.PackageName == "" {
+			if kind, _ := authType(source); kind == authJobToken {
+				return nil, fmt.Errorf("package_name is required with auth_type '%s'", kind)
+			}
+		} else if !packageNamePattern.MatchString(params.PackageName) {
+			return nil, fmt.Errorf("invalid package_name '%s'", params.PackageName)
+		}
+		if !packageNamePattern.MatchString(tag) {
+			return nil, fmt.Errorf("tag '%s' is not a valid generic package version", tag)
+		}
+		return &genericPackage{gitlab: client, name: params.PackageName, version: tag}, nil
+	case uploadToS3:
+		return newS3Store(client, source.S3, tag)
+	}
+	return nil, fmt.Errorf("invalid upload_to '%s': expected %s, %s or %s", params.UploadTo, uploadToProjectUpload, uploadToGenericPackage, uploadToS3)
+}
+
+// projectUploads stores assets as markdown uploads of the project.
+type projectUploads struct {
+	gitlab  GitLab
+	project *gitlab.Project
+	urls    *gitlabURLs
+}
+
+func (s *projectUploads) operation() string {
+	return opUploadAssets
+}
+
+func (s *projectUploads) linkType() gitlab.LinkTypeValue {
+	return gitlab.OtherLinkType
+}
+
+func (s *projectUploads) directAssetPath(file string) string {
+	return ""
+}
+
+func (s *projectUploads) store(ctx context.Context, file string) (ReleaseLinkSpec, error) {
+	if s.project == nil {
+		project, err := s.gitlab.GetProject(ctx)
+		if err != nil {
+			return ReleaseLinkSpec{}, err
+		}
+		if s.urls, err = newProjectURLs(project); err != nil {
+			return ReleaseLinkSpec{}, err
+		}
+		s.project = project
+	}
+
+	uploadedFile, err := s.gitlab.UploadProjectFile(ctx, file)
+	if err != nil {
+		return ReleaseLinkSpec{}, err
+	}
+	return ReleaseLinkSpec{
+		Name:     filepath.Base(file),
+		URL:      s.urls.upload(s.project, uploadedFile),
+		LinkType: gitlab.OtherLinkType,
+	}, nil
+}
+
+// genericPackage stores assets as files of a version of a generic package,
+// linked from the release by permalinks that do not change across versions
+// of the files.
+type genericPackage struct {
+	gitlab GitLab
+	// name of the package, defaulting to the path of the project
+	name    string
+	version string
+}
+
+func (s *genericPackage) operation() string {
+	return opPublishPackages
+}
+
+func (s *genericPackage) linkType() gitlab.LinkTypeValue {
+	return gitlab.PackageLinkType
+}
+
+func (s *genericPackage) directAssetPath(file string) string {
+	return "/" + filepath.Base(file)
+}
+
+func (s *genericPackage) store(ctx context.Context, file string) (ReleaseLinkSpec, error) {
+	if s.name == "" {
+		project, err := s.gitlab.GetProject(ctx)
+		if err != nil {
+			return ReleaseLinkSpec{}, err
+		}
+		s.name = project.Path
+	}
+
+	packageURL, err := s.gitlab.PublishPackageFile(ctx, s.name, s.version, file)
+	if err != nil {
+		return ReleaseLinkSpec{}, err
+	}
+	return ReleaseLinkSpec{
+		Name:            filepath.Base(file),
+		URL:             packageURL,
+		LinkType:        gitlab.PackageLinkType,
+		DirectAssetPath: s.directAssetPath(file),
+	}, nil
+}
+
+// s3Store stores assets as objects of an S3-compatible bucket, under
+// `<prefix>/<tag>/`, linked from the release by their public URL.
+type s3Store struct {
+	gitlab GitLab
+	// base URLs the escaped object keys are appended to
+	uploadBase string
+	linkBase   string
+	prefix     string
+}
+
+func newS3Store(client GitLab, storage *S3Storage, tag string) (*s3Store, error) {
+	if storage == nil || storage.Endpoint == "" || storage.Bucket == "" {
+		return nil, fmt.Errorf("upload_to '%s' requires the endpoint and bucket of the s3 source", uploadToS3)
+	}
+	for _, base := range []string{storage.Endpoint, storage.PublicURL} {
+		if base == "" {
+			continue
+		}
+		u, err := url.Parse(base)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid s3 URL '%s': expected an http or https URL", base)
+		}
+	}
+
+	// objects are addressed path-style, which every S3-compatible store supports
+	uploadBase := strings.TrimSuffix(storage.Endpoint, "/") + "/" + url.PathEscape(storage.Bucket) + "/"
+	linkBase := uploadBase
+	if storage.PublicURL != "" {
+		linkBase = strings.TrimSuffix(storage.PublicURL, "/") + "/"
+	}
+	prefix := tag + "/"
+	if p := strings.Trim(storage.Prefix, "/"); p != "" {
+		prefix = p + "/" + prefix
+	}
+	return &s3Store{gitlab: client, uploadBase: uploadBase, linkBase: linkBase, prefix: prefix}, nil
+}
+
+func (s *s3Store) operation() string {
+	return ""
+}
+
+func (s *s3Store) linkType() gitlab.LinkTypeValue {
+	return gitlab.OtherLinkType
+}
+
+func (s *s3Store) directAssetPath(file string) string {
+	return ""
+}
+
+func (s *s3Store) store(ctx context.Context, file string) (ReleaseLinkSpec, error) {
+	name := filepath.Base(file)
+	segments := strings.Split(s.prefix+name, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	key := strings.Join(segments, "/")
+
+	if err := s.gitlab.UploadObject(ctx, s.uploadBase+key, file); err != nil {
+		return ReleaseLinkSpec{}, err
+	}
+	return ReleaseLinkSpec{
+		Name:     name,
+		URL:      s.linkBase + key,
+		LinkType: gitlab.OtherLinkType,
+	}, nil
+}
@@ -0,0 +1,192 @@
+package resource
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// assetSync counts the changes made to the links of a release.
+type assetSync struct {
+	created   int
+	updated   int
+	deleted   int
+	unchanged int
+}
+
+func (s assetSync) metadata() []MetadataPair {
+	return []MetadataPair{
+		{Name: "assets_created", Value: strconv.Itoa(s.created)},
+		{Name: "assets_updated", Value: strconv.Itoa(s.updated)},
+		{Name: "assets_deleted", Value: strconv.Itoa(s.deleted)},
+		{Name: "assets_unchanged", Value: strconv.Itoa(s.unchanged)},
+	}
+}
+
+// checksumURL records the checksum of an asset in the fragment of its link
+// URL, as pip does with `#sha256=`. Fragments are never sent to servers.
+func checksumURL(linkURL string, sum string) string {
+	if i := strings.Index(linkURL, "#"); i >= 0 {
+		linkURL = linkURL[:i]
+	}
+	return linkURL + "#sha256=" + sum
+}
+
+// linkChecksum returns the checksum recorded in the link URL, if any.
+func linkChecksum(linkURL string) string {
+	u, err := url.Parse(linkURL)
+	if err != nil || !strings.HasPrefix(u.Fragment, "sha256=") {
+		return ""
+	}
+	return strings.TrimPrefix(u.Fragment, "sha256=")
+}
+
+// linkSpec describes an existing link, to restore it. GitLab only returns the
+// permalink of a direct asset, from which the path is recovered.
+func linkSpec(link *gitlab.ReleaseLink) ReleaseLinkSpec {
+	spec := ReleaseLinkSpec{Name: link.Name, URL: link.URL, LinkType: link.LinkType}
+	if link.DirectAssetURL == "" || link.DirectAssetURL == link.URL {
+		return spec
+	}
+	if i := strings.Index(link.DirectAssetURL, "/-/releases/"); i >= 0 {
+		permalink := link.DirectAssetURL[i:]
+		if j := strings.Index(permalink, "/downloads/"); j >= 0 {
+			spec.DirectAssetPath = permalink[j+len("/downloads"):]
+		}
+	}
+	return spec
+}
+
+// sameLinkType compares link types, links without a type being created as
+// `other`.
+func sameLinkType(a, b gitlab.LinkTypeValue) bool {
+	if a == "" {
+		a = gitlab.OtherLinkType
+	}
+	if b == "" {
+		b = gitlab.OtherLinkType
+	}
+	return a == b
+}
+
+// syncReleaseLinks publishes the assets as links of the release by name: only
+// new or changed files are stored, and links without a matching asset are
+// deleted last when prune is set. The asset names were checked to be distinct
+// by the preflight.
+func (c *OutCommand) syncReleaseLinks(ctx context.Context, tag string, store assetStore, assets []releaseAsset, prune bool, changes *journal) (assetSync, error) {
+	sync := assetSync{}
+
+	links, err := c.gitlab.GetReleaseLinks(ctx, tag)
+	if err != nil {
+		return sync, err
+	}
+	existing := map[string]*gitlab.ReleaseLink{}
+	for _, link := range links {
+		existing[link.Name] = link
+	}
+
+	names := map[string]bool{}
+	for _, asset := range assets {
+		names[asset.name] = true
+	}
+
+	for _, asset := range assets {
+		link := existing[asset.name]
+
+		var spec ReleaseLinkSpec
+		if asset.url != "" {
+			// external URLs are linked as they are, nothing is stored
+			spec = ReleaseLinkSpec{
+				Name:            asset.name,
+				URL:             asset.url,
+				LinkType:        asset.linkType,
+				DirectAssetPath: asset.directAssetPath,
+			}
+			if spec.LinkType == "" {
+				spec.LinkType = gitlab.OtherLinkType
+			}
+			if link != nil && link.URL == spec.URL && sameLinkType(link.LinkType, spec.LinkType) && (spec.DirectAssetPath == "" || linkSpec(link).DirectAssetPath == spec.DirectAssetPath) {
+				sync.unchanged++
+				continue
+			}
+		} else {
+			digest, err := digestFile(asset.file, sha256.New)
+			if err != nil {
+				return sync, err
+			}
+			sum := hex.EncodeToString(digest)
+
+			linkType := asset.linkType
+			if linkType == "" {
+				linkType = store.linkType()
+			}
+			if link != nil && linkChecksum(link.URL) == sum && sameLinkType(link.LinkType, linkType) && (asset.directAssetPath == "" || linkSpec(link).DirectAssetPath == asset.directAssetPath) {
+				sync.unchanged++
+				continue
+			}
+
+			if spec, err = store.store(ctx, asset.file); err != nil {
+				return sync, err
+			}
+			spec.Name = asset.name
+			spec.URL = checksumURL(spec.URL, sum)
+			spec.LinkType = linkType
+			if asset.directAssetPath != "" {
+				spec.DirectAssetPath = asset.directAssetPath
+			}
+		}
+
+		if link == nil {
+			created, err := c.gitlab.CreateReleaseLink(ctx, tag, spec)
+			if err != nil {
+				return sync, err
+			}
+			changes.record(fmt.Sprintf("deleting asset '%s'", asset.name), func(ctx context.Context) error {
+				return c.gitlab.DeleteReleaseLink(ctx, tag, created)
+			})
+			sync.created++
+			continue
+		}
+		previous := linkSpec(link)
+		if _, err := c.gitlab.UpdateReleaseLink(ctx, tag, link, spec); err != nil {
+			return sync, err
+		}
+		changes.record(fmt.Sprintf("restoring asset '%s'", asset.name), func(ctx context.Context) error {
+			if previous.DirectAssetPath == "" && spec.DirectAssetPath != "" {
+				// an update cannot clear the direct asset path, the link is recreated
+				if err := c.gitlab.DeleteReleaseLink(ctx, tag, link); err != nil {
+					return err
+				}
+				_, err := c.gitlab.CreateReleaseLink(ctx, tag, previous)
+				return err
+			}
+			_, err := c.gitlab.UpdateReleaseLink(ctx, tag, link, previous)
+			return err
+		})
+		sync.updated++
+	}
+
+	if !prune {
+		return sync, nil
+	}
+	for _, link := range links {
+		if names[link.Name] {
+			continue
+		}
+		if err := c.gitlab.DeleteReleaseLink(ctx, tag, link); err != nil {
+			return sync, err
+		}
+		changes.record(fmt.Sprintf("restoring asset '%s'", link.Name), func(ctx context.Context) error {
+			_, err := c.gitlab.CreateReleaseLink(ctx, tag, linkSpec(link))
+			return err
+		})
+		sync.deleted++
+	}
+	return sync, nil
+}
@@ -0,0 +1,256 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"go.yaml.in/yaml/v3"
+)
+
+var linkTypes = []gitlab.LinkTypeValue{
+	gitlab.OtherLinkType,
+	gitlab.RunbookLinkType,
+	gitlab.ImageLinkType,
+	gitlab.PackageLinkType,
+}
+
+// releaseAsset is an asset a put publishes: a file to store, or an external
+// URL to link.
+type releaseAsset struct {
+	name string
+	file string
+	url  string
+	// link type and direct asset path, left to the store when empty
+	linkType        gitlab.LinkTypeValue
+	directAssetPath string
+}
+
+// source describes where the asset comes from, for error messages.
+func (a releaseAsset) source() string {
+	if a.url != "" {
+		return a.url
+	}
+	return a.file
+}
+
+func storesFiles(assets []releaseAsset) bool {
+	for _, asset := range assets {
+		if asset.file != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// assetSpecs lists the assets of globs, assets and assets_manifest, with the
+// files relative to the source directory.
+func assetSpecs(sourceDir string, params OutParams) ([]AssetSpec, error) {
+	specs := []AssetSpec{}
+	for _, glob := range params.Globs {
+		specs = append(specs, AssetSpec{File: glob})
+	}
+	specs = append(specs, params.Assets...)
+
+	if params.AssetsManifest == "" {
+		return specs, nil
+	}
+	manifest, err := readAssetsManifest(filepath.Join(sourceDir, params.AssetsManifest))
+	if err != nil {
+		return nil, err
+	}
+	// files of the manifest are relative to it
+	manifestDir := filepath.Dir(params.AssetsManifest)
+	for _, spec := range manifest {
+		if spec.File != "" && !filepath.IsAbs(spec.File) {
+			spec.File = filepath.Join(manifestDir, spec.File)
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// readAssetsManifest reads a list of assets from a YAML or JSON file.
+// Unknown fields are refused, to catch typos such as `link-type`.
+func readAssetsManifest(manifestPath string) ([]AssetSpec, error) {
+	content, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	var document interface{}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("invalid assets_manifest '%s': %s", manifestPath, err)
+	}
+	// YAML being a superset of JSON, the document is decoded as JSON to
+	// share the field names of the assets param
+	normalized, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("invalid assets_manifest '%s': %s", manifestPath, err)
+	}
+	specs := []AssetSpec{}
+	decoder := json.NewDecoder(bytes.NewReader(normalized))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&specs); err != nil {
+		return nil, fmt.Errorf("invalid assets_manifest '%s': %s", manifestPath, err)
+	}
+	return specs, nil
+}
+
+// resolveAssets validates the specs and resolves their globs into readable
+// regular files within the size limits. Assets must be published under
+// distinct names and direct asset paths, including the ones the store gives
+// by default, and files stored under distinct base names.
+func resolveAssets(sourceDir string, specs []AssetSpec, params OutParams, store assetStore) ([]releaseAsset, error) {
+	maxAssetSize, maxTotalSize := int64(params.MaxAssetSize), int64(params.MaxTotalSize)
+
+	assets := []releaseAsset{}
+	seen := map[releaseAsset]bool{}
+	names := map[string]releaseAsset{}
+	stored := map[string]string{}
+	directAssetPaths := map[string]releaseAsset{}
+	totalSize := int64(0)
+
+	add := func(asset releaseAsset) error {
+		// an asset matched by several globs is published once
+		if seen[asset] {
+			return nil
+		}
+		seen[asset] = true
+
+		if other, ok := names[asset.name]; ok {
+			kind := "assets"
+			if other.file != "" && asset.file != "" {
+				kind = "files"
+			}
+			return fmt.Errorf("%s '%s' and '%s' would both be published as '%s'", kind, other.source(), asset.source(), asset.name)
+		}
+		names[asset.name] = asset
+
+		directAssetPath := asset.directAssetPath
+		if directAssetPath == "" && asset.file != "" {
+			directAssetPath = store.directAssetPath(asset.file)
+		}
+		if directAssetPath != "" {
+			if other, ok := directAssetPaths[directAssetPath]; ok {
+				return fmt.Errorf("assets '%s' and '%s' would both have the direct_asset_path '%s'", other.name, asset.name, directAssetPath)
+			}
+			directAssetPaths[directAssetPath] = asset
+		}
+		if asset.file == "" {
+			assets = append(assets, asset)
+			return nil
+		}
+
+		base := filepath.Base(asset.file)
+		if other, ok := stored[base]; ok && other != asset.file {
+			return fmt.Errorf("files '%s' and '%s' would both be stored as '%s'", other, asset.file, base)
+		}
+		stored[base] = asset.file
+
+		size, err := checkAssetFile(asset.file)
+		if err != nil {
+			return err
+		}
+		if maxAssetSize > 0 && size > maxAssetSize {
+			return fmt.Errorf("file '%s' exceeds max_asset_size of %d bytes: %d bytes", asset.name, maxAssetSize, size)
+		}
+		totalSize += size
+		if maxTotalSize > 0 && totalSize > maxTotalSize {
+			return fmt.Errorf("file '%s' exceeds max_total_size of %d bytes: %d bytes in total", asset.name, maxTotalSize, totalSize)
+		}
+		assets = append(assets, asset)
+		return nil
+	}
+
+	for _, spec := range specs {
+		asset, err := newReleaseAsset(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		if spec.URL != "" {
+			if err := add(asset); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(sourceDir, spec.File))
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("could not find file that matches glob '%s'", spec.File)
+		}
+		if len(matches) > 1 && (spec.Name != "" || spec.DirectAssetPath != "") {
+			return nil, fmt.Errorf("asset '%s' matches %d files and cannot have a name or direct_asset_path", spec.File, len(matches))
+		}
+		for _, file := range matches {
+			asset.file = file
+			if spec.Name == "" {
+				asset.name = filepath.Base(file)
+			}
+			if err := add(asset); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return assets, nil
+}
+
+// newReleaseAsset validates the spec, leaving the globs to resolve.
+func newReleaseAsset(spec AssetSpec) (releaseAsset, error) {
+	asset := releaseAsset{
+		name:            spec.Name,
+		linkType:        gitlab.LinkTypeValue(spec.LinkType),
+		directAssetPath: spec.DirectAssetPath,
+	}
+
+	switch {
+	case spec.File != "" && spec.URL != "":
+		return asset, fmt.Errorf("asset '%s' cannot have both a file and a url", spec.File)
+	case spec.File == "" && spec.URL == "":
+		return asset, fmt.Errorf("asset '%s' must have a file or a url", spec.Name)
+	}
+
+	if asset.linkType != "" && !validLinkType(asset.linkType) {
+		names := []string{}
+		for _, linkType := range linkTypes {
+			names = append(names, string(linkType))
+		}
+		return asset, fmt.Errorf("invalid link_type '%s': expected %s", spec.LinkType, strings.Join(names, ", "))
+	}
+	if asset.directAssetPath != "" && !strings.HasPrefix(asset.directAssetPath, "/") {
+		asset.directAssetPath = "/" + asset.directAssetPath
+	}
+
+	if spec.URL != "" {
+		u, err := url.Parse(spec.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return asset, fmt.Errorf("invalid url '%s' of asset: expected an http or https URL", spec.URL)
+		}
+		asset.url = spec.URL
+		if asset.name == "" {
+			asset.name = path.Base(u.Path)
+		}
+		if asset.name == "" || asset.name == "/" || asset.name == "." {
+			return asset, fmt.Errorf("asset '%s' needs a name", spec.URL)
+		}
+	}
+	return asset, nil
+}
+
+func validLinkType(linkType gitlab.LinkTypeValue) bool {
+	for _, valid := range linkTypes {
+		if linkType == valid {
+			return true
+		}
+	}
+	return false
+}
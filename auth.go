@@ -0,0 +1,122 @@
+package resource
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"golang.org/x/oauth2"
+)
+
+const (
+	authPrivateToken = "private_token"
+	authJobToken     = "job_token"
+	authOAuth        = "oauth"
+	authBasic        = "basic"
+)
+
+// operations that out performs and some auth types are not allowed to.
+const (
+	opWriteRelease    = "create or update releases"
+	opCreateTag       = "create tags"
+	opUploadAssets    = "upload assets"
+	opPublishPackages = "publish packages"
+)
+
+// unsupportedOperations lists what GitLab refuses to each auth type: CI job
+// tokens are limited to the releases API and the package registry, deploy
+// tokens to the registries.
+var unsupportedOperations = map[string][]string{
+	authJobToken: {opCreateTag, opUploadAssets},
+	authBasic:    {opWriteRelease, opCreateTag, opUploadAssets},
+}
+
+// authType returns the auth_type of the source, `deploy_token` being an
+// alias of `basic`.
+func authType(source Source) (string, error) {
+	switch source.AuthType {
+	case "", authPrivateToken:
+		return authPrivateToken, nil
+	case authJobToken, authOAuth:
+		return source.AuthType, nil
+	case authBasic, "deploy_token":
+		if source.Username == "" {
+			return "", fmt.Errorf("auth_type '%s' requires a username", source.AuthType)
+		}
+		return authBasic, nil
+	}
+	return "", fmt.Errorf("invalid auth_type '%s': expected private_token, job_token, oauth or basic", source.AuthType)
+}
+
+// checkOperations fails when the auth type of the source cannot perform all
+// the given operations.
+func checkOperations(source Source, operations ...string) error {
+	kind, err := authType(source)
+	if err != nil {
+		return err
+	}
+	denied := []string{}
+	for _, operation := range operations {
+		for _, unsupported := range unsupportedOperations[kind] {
+			if operation == unsupported {
+				denied = append(denied, operation)
+			}
+		}
+	}
+	if len(denied) == 0 {
+		return nil
+	}
+	return fmt.Errorf("auth_type '%s' cannot %s: use a private_token or oauth token with the api scope", kind, strings.Join(denied, " or "))
+}
+
+// basicAuthSource authenticates API calls with HTTP basic auth, as used by
+// deploy tokens.
+type basicAuthSource struct {
+	username string
+	password string
+}
+
+func (basicAuthSource) Init(context.Context, *gitlab.Client) error {
+	return nil
+}
+
+func (s basicAuthSource) Header(context.Context) (string, string, error) {
+	credentials := base64.StdEncoding.EncodeToString([]byte(s.username + ":" + s.password))
+	return "Authorization", "Basic " + credentials, nil
+}
+
+// newAuthSource returns the API authentication matching the auth type.
+func newAuthSource(kind string, source Source) gitlab.AuthSource {
+	switch kind {
+	case authJobToken:
+		return gitlab.JobTokenAuthSource{Token: source.AccessToken}
+	case authOAuth:
+		return gitlab.OAuthTokenSource{
+			TokenSource: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: source.AccessToken}),
+		}
+	case authBasic:
+		return basicAuthSource{username: source.Username, password: source.AccessToken}
+	}
+	return gitlab.AccessTokenAuthSource{Token: source.AccessToken}
+}
+
+// authenticate sets the credentials of the auth type on a download from the
+// GitLab host.
+func authenticate(req *http.Request, kind string, username string, token string) {
+	if token == "" {
+		return
+	}
+	switch kind {
+	case authJobToken:
+		req.Header.Set(gitlab.JobTokenHeaderName, token)
+	case authOAuth:
+		req.Header.Set("Authorization", "Bearer "+token)
+	case authBasic:
+		req.SetBasicAuth(username, token)
+	default:
+		req.Header.Set("Private-Token", token)
+	}
+}
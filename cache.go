@@ -0,0 +1,292 @@
+package resource
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FileValidator holds the HTTP validators of a downloaded file, used to
+// revalidate a cached copy with a conditional request.
+type FileValidator struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+func (v FileValidator) empty() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// downloadCache is a content-addressed store of downloaded assets shared
+// between gets. Objects are stored under objects/<sha256> and indexed by URL
+// under index/<sha256 of url>.json, whose modification time records when the
+// entry was last used.
+//
+// Gets hold a shared lock of the cache while they download, and eviction an
+// exclusive one, so that objects are never evicted while a get stores or
+// links them.
+type downloadCache struct {
+	dir     string
+	maxSize int64
+	maxAge  time.Duration
+	// hard link objects into the destination instead of copying them
+	hardLinks bool
+}
+
+type cacheEntry struct {
+	URL       string        `json:"url"`
+	SHA256    string        `json:"sha256"`
+	Size      int64         `json:"size"`
+	Validator FileValidator `json:"validator"`
+
+	lastUsed time.Time
+}
+
+func newDownloadCache(dir string, maxSize int64, maxAge time.Duration, hardLinks bool) (*downloadCache, error) {
+	for _, sub := range []string{"objects", "index", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("cannot create cache directory: %s", err)
+		}
+	}
+	return &downloadCache{dir: dir, maxSize: maxSize, maxAge: maxAge, hardLinks: hardLinks}, nil
+}
+
+func (c *downloadCache) lockPath() string {
+	return filepath.Join(c.dir, "lock")
+}
+
+// lock takes the shared lock of the gets and returns the function releasing
+// it.
+func (c *downloadCache) lock() (func() error, error) {
+	release, _, err := lockFile(c.lockPath(), false, true)
+	return release, err
+}
+
+func (c *downloadCache) indexPath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, "index", hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *downloadCache) objectPath(sum string) string {
+	return filepath.Join(c.dir, "objects", sum)
+}
+
+// tempFile returns a path to download into, on the same filesystem as the
+// objects so that it can be moved into the store.
+func (c *downloadCache) tempFile() (string, error) {
+	f, err := os.CreateTemp(filepath.Join(c.dir, "tmp"), "download-")
+	if err != nil {
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
+
+// lookup returns the entry of the URL, or nil when it is not cached or when
+// its checksum differs from the expected one, e.g. when the asset was
+// uploaded again under the same URL.
+func (c *downloadCache) lookup(url string, expectedSum string) *cacheEntry {
+	data, err := os.ReadFile(c.indexPath(url))
+	if err != nil {
+		return nil
+	}
+	entry := &cacheEntry{}
+	if err := json.Unmarshal(data, entry); err != nil || entry.URL != url {
+		return nil
+	}
+	if expectedSum != "" && entry.SHA256 != expectedSum {
+		return nil
+	}
+	info, err := os.Stat(c.objectPath(entry.SHA256))
+	if err != nil || info.Size() != entry.Size {
+		return nil
+	}
+	return entry
+}
+
+// store moves the downloaded file into the cache and indexes it under url.
+func (c *downloadCache) store(url string, file string, validator FileValidator) (*cacheEntry, error) {
+	digest, err := digestFile(file, sha256.New)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, err
+	}
+	entry := &cacheEntry{
+		URL:       url,
+		SHA256:    hex.EncodeToString(digest),
+		Size:      info.Size(),
+		Validator: validator,
+	}
+
+	object := c.objectPath(entry.SHA256)
+	if _, err := os.Stat(object); err == nil {
+		// same content already stored for another URL or validator
+		if err := os.Remove(file); err != nil {
+			return nil, err
+		}
+	} else {
+		// objects are shared through hard links, protect them from edits
+		if err := os.Chmod(file, 0444); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(file, object); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	// written aside then moved, as other gets may read or write it too
+	tmp, err := c.tempFile()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	return entry, os.Rename(tmp, c.indexPath(url))
+}
+
+// touch marks the entry as used now, so that it is evicted last.
+func (c *downloadCache) touch(entry *cacheEntry) error {
+	now := time.Now()
+	return os.Chtimes(c.indexPath(entry.URL), now, now)
+}
+
+// link places the cached object at destPath as a writable copy. With
+// hardLinks, it is hard linked when possible, and then read-only as the
+// objects are.
+func (c *downloadCache) link(entry *cacheEntry, destPath string) error {
+	object := c.objectPath(entry.SHA256)
+	if err := os.Remove(destPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if c.hardLinks {
+		if err := os.Link(object, destPath); err == nil {
+			return nil
+		}
+	}
+
+	in, err := os.Open(object)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(destPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func (c *downloadCache) entries() ([]*cacheEntry, error) {
+	paths, err := filepath.Glob(filepath.Join(c.dir, "index", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*cacheEntry{}
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		entry := &cacheEntry{lastUsed: info.ModTime()}
+		if err := json.Unmarshal(data, entry); err != nil {
+			// drop corrupted entries
+			if err := os.Remove(p); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// evict removes the entries unused for longer than maxAge, then the least
+// recently used ones until the objects fit in maxSize, and finally the
+// objects no entry refers to anymore and the files left in tmp by gets that
+// crashed. It is skipped while other gets use the cache.
+func (c *downloadCache) evict() (bool, error) {
+	release, ok, err := lockFile(c.lockPath(), true, false)
+	if err != nil || !ok {
+		return false, err
+	}
+	defer release()
+	return true, c.evictLocked()
+}
+
+func (c *downloadCache) evictLocked() error {
+	// no get is downloading while the exclusive lock is held
+	tmps, err := os.ReadDir(filepath.Join(c.dir, "tmp"))
+	if err != nil {
+		return err
+	}
+	for _, tmp := range tmps {
+		if err := os.Remove(filepath.Join(c.dir, "tmp", tmp.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	entries, err := c.entries()
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].lastUsed.After(entries[j].lastUsed)
+	})
+
+	kept := map[string]bool{}
+	size := int64(0)
+	for _, entry := range entries {
+		expired := c.maxAge > 0 && time.Since(entry.lastUsed) > c.maxAge
+		if !expired && !kept[entry.SHA256] {
+			expired = c.maxSize > 0 && size+entry.Size > c.maxSize
+			if !expired {
+				size += entry.Size
+			}
+		}
+		if expired {
+			if err := os.Remove(c.indexPath(entry.URL)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			continue
+		}
+		kept[entry.SHA256] = true
+	}
+
+	objects, err := os.ReadDir(filepath.Join(c.dir, "objects"))
+	if err != nil {
+		return err
+	}
+	for _, object := range objects {
+		if kept[object.Name()] {
+			continue
+		}
+		if err := os.Remove(c.objectPath(object.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
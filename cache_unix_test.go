@@ -0,0 +1,98 @@
+//go:build unix
+
+package resource_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+
+	resource "github.com/orange-cloudfoundry/gitlab-release-resource"
+	"github.com/orange-cloudfoundry/gitlab-release-resource/fakes"
+)
+
+var _ = Describe("Download cache", func() {
+	var (
+		gitlabClient *fakes.FakeGitLab
+		output       *bytes.Buffer
+		command      *resource.InCommand
+		request      resource.InRequest
+		tmpDir       string
+		cacheDir     string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = os.MkdirTemp("", "gitlab-cache")
+		Ω(err).ShouldNot(HaveOccurred())
+		cacheDir = filepath.Join(tmpDir, "cache")
+
+		gitlabClient = &fakes.FakeGitLab{}
+		gitlabClient.GetReleaseReturns(&gitlab.Release{
+			TagName: "v1.0.0",
+			Assets: gitlab.ReleaseAssets{
+				Links: []*gitlab.ReleaseLink{{ID: 1, Name: "asset.bin", URL: "asset.bin"}},
+			},
+		}, nil)
+		gitlabClient.DownloadProjectFileIfModifiedStub = func(_ context.Context, url string, destPath string, maxSize int64, validator resource.FileValidator) (resource.FileValidator, int64, error) {
+			return resource.FileValidator{}, 5, os.WriteFile(destPath, []byte("asset"), 0644)
+		}
+		output = &bytes.Buffer{}
+		command = resource.NewInCommand(gitlabClient, output)
+		request = resource.InRequest{
+			Version: &resource.Version{Tag: "v1.0.0"},
+			Params:  resource.InParams{CacheDir: cacheDir, CacheMaxSize: 1},
+		}
+	})
+
+	AfterEach(func() {
+		Ω(os.RemoveAll(tmpDir)).Should(Succeed())
+	})
+
+	It("removes the files left in tmp by gets that crashed", func() {
+		Ω(os.MkdirAll(filepath.Join(cacheDir, "tmp"), 0755)).Should(Succeed())
+		file(filepath.Join(cacheDir, "tmp", "download-123"), "partial")
+
+		_, err := command.Run(context.Background(), filepath.Join(tmpDir, "dest"), request)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(filepath.Join(cacheDir, "tmp", "download-123")).ShouldNot(BeAnExistingFile())
+	})
+
+	It("does not evict while another get uses the cache", func() {
+		Ω(os.MkdirAll(cacheDir, 0755)).Should(Succeed())
+		lock, err := os.OpenFile(filepath.Join(cacheDir, "lock"), os.O_CREATE|os.O_RDWR, 0644)
+		Ω(err).ShouldNot(HaveOccurred())
+		defer lock.Close()
+		Ω(syscall.Flock(int(lock.Fd()), syscall.LOCK_SH)).Should(Succeed())
+
+		_, err = command.Run(context.Background(), filepath.Join(tmpDir, "dest"), request)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(output.String()).Should(ContainSubstring("cache in use by other gets, eviction skipped"))
+		objects, err := filepath.Glob(filepath.Join(cacheDir, "objects", "*"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(objects).Should(HaveLen(1))
+	})
+
+	It("waits for the eviction before storing assets", func() {
+		Ω(os.MkdirAll(cacheDir, 0755)).Should(Succeed())
+		lock, err := os.OpenFile(filepath.Join(cacheDir, "lock"), os.O_CREATE|os.O_RDWR, 0644)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(syscall.Flock(int(lock.Fd()), syscall.LOCK_EX)).Should(Succeed())
+
+		done := make(chan error)
+		go func() {
+			_, err := command.Run(context.Background(), filepath.Join(tmpDir, "dest"), request)
+			done <- err
+		}()
+		Consistently(done).ShouldNot(Receive())
+		Ω(lock.Close()).Should(Succeed())
+		Eventually(done).Should(Receive(BeNil()))
+	})
+})
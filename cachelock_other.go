@@ -0,0 +1,8 @@
+//go:build !unix
+
+package resource
+
+// lockFile is not supported on this platform, the cache is not locked.
+func lockFile(path string, exclusive bool, wait bool) (release func() error, ok bool, err error) {
+	return func() error { return nil }, true, nil
+}
@@ -0,0 +1,35 @@
+//go:build unix
+
+package resource
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile takes a shared or exclusive flock on the file and returns the
+// function releasing it. With wait false, ok is false when the lock is held
+// elsewhere.
+func lockFile(path string, exclusive bool, wait bool) (release func() error, ok bool, err error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, false, err
+	}
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	if !wait {
+		how |= syscall.LOCK_NB
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	// closing the file releases the lock
+	return f.Close, true, nil
+}
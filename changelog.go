@@ -0,0 +1,162 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// maxMergeRequestLookups bounds the API calls made to find the merge requests
+// of the commits, one per commit.
+const maxMergeRequestLookups = 100
+
+type Changelog struct {
+	From          string                  `json:"from"`
+	To            string                  `json:"to"`
+	CompareURL    string                  `json:"compare_url,omitempty"`
+	Commits       []ChangelogCommit       `json:"commits"`
+	MergeRequests []ChangelogMergeRequest `json:"merge_requests"`
+	// MergeRequestsTruncated is set when the merge requests of the commits
+	// beyond maxMergeRequestLookups were not looked up.
+	MergeRequestsTruncated bool `json:"merge_requests_truncated,omitempty"`
+}
+
+type ChangelogCommit struct {
+	ID          string `json:"id"`
+	ShortID     string `json:"short_id"`
+	Title       string `json:"title"`
+	AuthorName  string `json:"author_name"`
+	AuthorEmail string `json:"author_email"`
+	URL         string `json:"url"`
+}
+
+type ChangelogMergeRequest struct {
+	IID    int64    `json:"iid"`
+	Title  string   `json:"title"`
+	Author string   `json:"author"`
+	URL    string   `json:"url"`
+	Labels []string `json:"labels"`
+}
+
+// buildChangelog lists the commits and merged merge requests between the
+// previous release and the given one.
+func buildChangelog(ctx context.Context, client GitLab, previous *gitlab.Release, release *gitlab.Release) (Changelog, error) {
+	changelog := Changelog{
+		To:            release.TagName,
+		Commits:       []ChangelogCommit{},
+		MergeRequests: []ChangelogMergeRequest{},
+	}
+
+	// first release, nothing to compare with
+	if previous == nil {
+		return changelog, nil
+	}
+	changelog.From = previous.TagName
+
+	compare, err := client.CompareRefs(ctx, previous.TagName, release.TagName)
+	if err != nil {
+		return Changelog{}, err
+	}
+	changelog.CompareURL = compare.WebURL
+
+	seen := map[int64]bool{}
+	for i, commit := range compare.Commits {
+		changelog.Commits = append(changelog.Commits, ChangelogCommit{
+			ID:          commit.ID,
+			ShortID:     commit.ShortID,
+			Title:       commit.Title,
+			AuthorName:  commit.AuthorName,
+			AuthorEmail: commit.AuthorEmail,
+			URL:         commit.WebURL,
+		})
+
+		if i >= maxMergeRequestLookups {
+			changelog.MergeRequestsTruncated = true
+			continue
+		}
+		mrs, err := client.ListMergeRequestsByCommit(ctx, commit.ID)
+		if err != nil {
+			return Changelog{}, err
+		}
+		for _, mr := range mrs {
+			if mr.State != "merged" || seen[mr.IID] {
+				continue
+			}
+			seen[mr.IID] = true
+
+			author := ""
+			if mr.Author != nil {
+				author = mr.Author.Username
+			}
+			labels := []string{}
+			labels = append(labels, mr.Labels...)
+			changelog.MergeRequests = append(changelog.MergeRequests, ChangelogMergeRequest{
+				IID:    mr.IID,
+				Title:  mr.Title,
+				Author: author,
+				URL:    mr.WebURL,
+				Labels: labels,
+			})
+		}
+	}
+	return changelog, nil
+}
+
+func (c Changelog) Markdown() string {
+	var b strings.Builder
+
+	if c.From == "" {
+		fmt.Fprintf(&b, "## %s\n\nFirst release, no previous release to compare with.\n", c.To)
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "## Changes from %s to %s\n", c.From, c.To)
+	if c.CompareURL != "" {
+		fmt.Fprintf(&b, "\n[Full comparison](%s)\n", c.CompareURL)
+	}
+
+	if len(c.MergeRequests) > 0 {
+		b.WriteString("\n### Merge requests\n\n")
+		for _, mr := range c.MergeRequests {
+			fmt.Fprintf(&b, "* [!%d](%s) %s", mr.IID, mr.URL, mr.Title)
+			if mr.Author != "" {
+				fmt.Fprintf(&b, " (@%s)", mr.Author)
+			}
+			if len(mr.Labels) > 0 {
+				fmt.Fprintf(&b, " `%s`", strings.Join(mr.Labels, "` `"))
+			}
+			b.WriteString("\n")
+		}
+	}
+	if c.MergeRequestsTruncated {
+		fmt.Fprintf(&b, "\nOnly the merge requests of the first %d commits are listed.\n", maxMergeRequestLookups)
+	}
+
+	if len(c.Commits) > 0 {
+		b.WriteString("\n### Commits\n\n")
+		for _, commit := range c.Commits {
+			if commit.URL != "" {
+				fmt.Fprintf(&b, "* [%s](%s) %s (%s)\n", commit.ShortID, commit.URL, commit.Title, commit.AuthorName)
+			} else {
+				fmt.Fprintf(&b, "* %s %s (%s)\n", commit.ShortID, commit.Title, commit.AuthorName)
+			}
+		}
+	}
+	return b.String()
+}
+
+func (c Changelog) write(destDir string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(destDir, "changes.json"), data, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(destDir, "changes.md"), []byte(c.Markdown()), 0644)
+}
@@ -1,7 +1,7 @@
 package resource
 
 import (
-	"sort"
+	"context"
 
 	"github.com/cppforlife/go-semi-semantic/version"
 	gitlab "gitlab.com/gitlab-org/api/client-go"
@@ -17,14 +17,14 @@ func NewCheckCommand(gitlab GitLab) *CheckCommand {
 	}
 }
 
-func (c *CheckCommand) Run(request CheckRequest) ([]Version, error) {
+func (c *CheckCommand) Run(ctx context.Context, request CheckRequest) ([]Version, error) {
 	versionParser, err := newVersionParser(request.Source.TagFilter)
 	if err != nil {
 		return []Version{}, err
 	}
 
 	// fetch available releases
-	releases, err := c.gitlab.ListReleases()
+	releases, err := c.gitlab.ListReleases(ctx)
 	if err != nil {
 		return []Version{}, err
 	}
@@ -36,26 +36,16 @@ func (c *CheckCommand) Run(request CheckRequest) ([]Version, error) {
 		return []Version{}, err
 	}
 
-	for _, r := range releases {
-		current, err := version.NewVersionFromString(versionParser.parse(r.TagName))
-		// must match tag regex
-		if err != nil {
-			continue
-		}
-		// when given, keep only releases greater-or-equal than the target version
+	// keep releases greater-or-equal than the target version, when given,
+	// sorted from older to newer
+	for _, r := range sortReleases(releases, versionParser) {
+		// errors ignored since has already been filtered out by sortReleases
+		current, _ := version.NewVersionFromString(versionParser.parse(r.TagName))
 		if (request.Version == Version{}) || !current.IsLt(targetVersion) {
 			filteredReleases = append(filteredReleases, r)
 		}
 	}
 
-	// sort releases from older to newer
-	sort.Slice(filteredReleases, func(i, j int) bool {
-		// errors ignored since has already been filtered out by regexp
-		first, _ := version.NewVersionFromString(versionParser.parse(filteredReleases[i].Name))
-		second, _ := version.NewVersionFromString(versionParser.parse(filteredReleases[j].Name))
-		return first.IsLt(second)
-	})
-
 	// no version available
 	if len(filteredReleases) == 0 {
 		return []Version{}, nil
@@ -1,6 +1,7 @@
 package resource_test
 
 import (
+	"context"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -59,7 +60,7 @@ var _ = Describe("Check Command", func() {
 			})
 
 			It("detects no version", func() {
-				versions, err := command.Run(*request)
+				versions, err := command.Run(context.Background(), *request)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(versions).Should(BeEmpty())
 			})
@@ -74,7 +75,7 @@ var _ = Describe("Check Command", func() {
 			})
 
 			It("detects no version", func() {
-				versions, err := command.Run(*request)
+				versions, err := command.Run(context.Background(), *request)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(versions).Should(BeEmpty())
 			})
@@ -91,7 +92,7 @@ var _ = Describe("Check Command", func() {
 				request.Version = resource.Version{}
 			})
 			It("detects a single version", func() {
-				versions, err := command.Run(*request)
+				versions, err := command.Run(context.Background(), *request)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(versions).Should(HaveLen(1))
 				Ω(versions).Should(Equal([]resource.Version{
@@ -108,7 +109,7 @@ var _ = Describe("Check Command", func() {
 				}
 			})
 			It("detects a single version", func() {
-				versions, err := command.Run(*request)
+				versions, err := command.Run(context.Background(), *request)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(versions).Should(HaveLen(1))
 				Ω(versions).Should(Equal([]resource.Version{
@@ -125,7 +126,7 @@ var _ = Describe("Check Command", func() {
 				}
 			})
 			It("detects the requested version", func() {
-				versions, err := command.Run(*request)
+				versions, err := command.Run(context.Background(), *request)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(versions).Should(HaveLen(1))
 				Ω(versions).Should(Equal([]resource.Version{
@@ -145,7 +146,7 @@ var _ = Describe("Check Command", func() {
 				request.Version = resource.Version{}
 			})
 			It("detects a last available version", func() {
-				versions, err := command.Run(*request)
+				versions, err := command.Run(context.Background(), *request)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(versions).Should(HaveLen(1))
 				Ω(versions).Should(Equal([]resource.Version{
@@ -161,7 +162,7 @@ var _ = Describe("Check Command", func() {
 					request.Version.Tag = "v0.0.1"
 				})
 				It("detects all versions correclty", func() {
-					versions, err := command.Run(*request)
+					versions, err := command.Run(context.Background(), *request)
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(versions).Should(HaveLen(7))
 					Ω(versions).Should(Equal([]resource.Version{
@@ -181,7 +182,7 @@ var _ = Describe("Check Command", func() {
 					request.Version.Tag = "v5.1.0"
 				})
 				It("replies with already known version", func() {
-					versions, err := command.Run(*request)
+					versions, err := command.Run(context.Background(), *request)
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(versions).Should(HaveLen(1))
 					Ω(versions).Should(Equal([]resource.Version{
@@ -195,7 +196,7 @@ var _ = Describe("Check Command", func() {
 					request.Version.Tag = "v2.5.0"
 				})
 				It("replies with all version greater than requested", func() {
-					versions, err := command.Run(*request)
+					versions, err := command.Run(context.Background(), *request)
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(versions).Should(HaveLen(3))
 					Ω(versions).Should(Equal([]resource.Version{
@@ -212,7 +213,7 @@ var _ = Describe("Check Command", func() {
 					request.Version.Tag = "v0.1.0"
 				})
 				It("replies with all version greater than requested", func() {
-					versions, err := command.Run(*request)
+					versions, err := command.Run(context.Background(), *request)
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(versions).Should(HaveLen(3))
 					Ω(versions).Should(Equal([]resource.Version{
@@ -225,6 +226,23 @@ var _ = Describe("Check Command", func() {
 		})
 	})
 
+	Context("When release names differ from their tags", func() {
+		BeforeEach(func() {
+			gitlabClient.ListReleasesReturns([]*gitlab.Release{
+				{Name: "v2.0.0", TagName: "v1.0.0", Commit: gitlab.Commit{ID: "first"}},
+				{Name: "v1.0.0", TagName: "v2.0.0", Commit: gitlab.Commit{ID: "second"}},
+			}, nil)
+		})
+
+		It("orders releases by tag", func() {
+			versions, err := command.Run(context.Background(), *request)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(versions).Should(Equal([]resource.Version{
+				{Tag: "v2.0.0", CommitSHA: "second"},
+			}))
+		})
+	})
+
 	Context("When dealing with complex postrelease and prerelease versions", func() {
 		BeforeEach(func() {
 			messy_version := append(many_version, []string{
@@ -247,7 +265,7 @@ var _ = Describe("Check Command", func() {
 					request.Source.TagFilter = "v?([0-9]+.*)"
 				})
 				It("detects all versions correclty", func() {
-					versions, err := command.Run(*request)
+					versions, err := command.Run(context.Background(), *request)
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(versions).Should(Equal([]resource.Version{
 						{Tag: "v1.0.0-dev1", CommitSHA: "dabdab"},
@@ -0,0 +1,125 @@
+package resource
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// checksumsMarker starts the checksum table appended to the release
+// description, which is replaced on each put.
+const checksumsMarker = "<!-- generated checksums -->"
+
+// checksumAlgorithms are the algorithms of generate_checksums, with the name
+// of the file listing the digests as sha256sum does.
+var checksumAlgorithms = map[string]struct {
+	newHash  func() hash.Hash
+	fileName string
+}{
+	"sha256": {sha256.New, "SHA256SUMS"},
+	"sha512": {sha512.New, "SHA512SUMS"},
+}
+
+// checksums holds the digests of the uploaded files, by algorithm in the
+// order of generate_checksums.
+type checksums struct {
+	algorithms []string
+	names      []string
+	digests    map[string][]string
+}
+
+// newChecksums validates the algorithms of generate_checksums.
+func newChecksums(algorithms []string) (*checksums, error) {
+	c := &checksums{digests: map[string][]string{}}
+	for _, algorithm := range algorithms {
+		if _, ok := checksumAlgorithms[algorithm]; !ok {
+			return nil, fmt.Errorf("invalid generate_checksums algorithm '%s': expected sha256 or sha512", algorithm)
+		}
+		if _, ok := c.digests[algorithm]; ok {
+			continue
+		}
+		c.algorithms = append(c.algorithms, algorithm)
+		c.digests[algorithm] = []string{}
+	}
+	return c, nil
+}
+
+// generate computes the digests of the files among the assets and writes a
+// checksums file per algorithm in dir, returned as assets to publish.
+func (c *checksums) generate(assets []releaseAsset, dir string) ([]releaseAsset, error) {
+	for _, asset := range assets {
+		if asset.file == "" {
+			continue
+		}
+		for _, algorithm := range c.algorithms {
+			if asset.name == checksumAlgorithms[algorithm].fileName || filepath.Base(asset.file) == checksumAlgorithms[algorithm].fileName {
+				return nil, fmt.Errorf("asset '%s' would be replaced by the %s generated by generate_checksums", asset.file, checksumAlgorithms[algorithm].fileName)
+			}
+		}
+		c.names = append(c.names, asset.name)
+	}
+	if len(c.names) == 0 {
+		return nil, nil
+	}
+
+	generated := []releaseAsset{}
+	for _, algorithm := range c.algorithms {
+		var content strings.Builder
+		for _, asset := range assets {
+			if asset.file == "" {
+				continue
+			}
+			digest, err := digestFile(asset.file, checksumAlgorithms[algorithm].newHash)
+			if err != nil {
+				return nil, err
+			}
+			sum := hex.EncodeToString(digest)
+			c.digests[algorithm] = append(c.digests[algorithm], sum)
+			fmt.Fprintf(&content, "%s  %s\n", sum, asset.name)
+		}
+
+		name := checksumAlgorithms[algorithm].fileName
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(content.String()), 0644); err != nil {
+			return nil, err
+		}
+		generated = append(generated, releaseAsset{name: name, file: file})
+	}
+	return generated, nil
+}
+
+// table renders the digests as a markdown table, starting with the marker.
+func (c *checksums) table() string {
+	var table strings.Builder
+	table.WriteString(checksumsMarker + "\n\n| Asset |")
+	for _, algorithm := range c.algorithms {
+		table.WriteString(" " + strings.ToUpper(algorithm) + " |")
+	}
+	table.WriteString("\n| --- |" + strings.Repeat(" --- |", len(c.algorithms)) + "\n")
+	for i, name := range c.names {
+		table.WriteString("| " + strings.ReplaceAll(name, "|", `\|`) + " |")
+		for _, algorithm := range c.algorithms {
+			table.WriteString(" `" + c.digests[algorithm][i] + "` |")
+		}
+		table.WriteString("\n")
+	}
+	return table.String()
+}
+
+// withChecksumTable replaces the checksum table of a former put at the end of
+// the description with the table.
+func withChecksumTable(description string, table string) string {
+	if i := strings.Index(description, checksumsMarker); i >= 0 {
+		description = description[:i]
+	}
+	description = strings.TrimRight(description, "\n")
+	if description == "" {
+		return table
+	}
+	return description + "\n\n" + table
+}
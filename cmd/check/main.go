@@ -17,7 +17,17 @@ func main() {
 	}
 
 	command := resource.NewCheckCommand(gitlab)
-	response, err := command.Run(request)
+	ctx, cancel := resource.NewContext(request.Source)
+	if err := resource.CheckAccessToken(ctx, gitlab, request.Source, os.Stderr, "read_api"); err != nil {
+		cancel()
+		resource.Fatal("checking access token", err)
+	}
+	if err := resource.ResolveProject(ctx, gitlab, request.Source, os.Stderr); err != nil {
+		cancel()
+		resource.Fatal("resolving repository", err)
+	}
+	response, err := command.Run(ctx, request)
+	cancel()
 	if err != nil {
 		resource.Fatal("running command", err)
 	}
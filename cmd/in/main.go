@@ -24,7 +24,17 @@ func main() {
 	}
 
 	command := resource.NewInCommand(gitlab, os.Stderr)
-	response, err := command.Run(destDir, request)
+	ctx, cancel := resource.NewContext(request.Source)
+	if err := resource.CheckAccessToken(ctx, gitlab, request.Source, os.Stderr, "read_api"); err != nil {
+		cancel()
+		resource.Fatal("checking access token", err)
+	}
+	if err := resource.ResolveProject(ctx, gitlab, request.Source, os.Stderr); err != nil {
+		cancel()
+		resource.Fatal("resolving repository", err)
+	}
+	response, err := command.Run(ctx, destDir, request)
+	cancel()
 	if err != nil {
 		resource.Fatal("running command", err)
 	}
@@ -24,7 +24,17 @@ func main() {
 	}
 
 	command := resource.NewOutCommand(gitlab, os.Stderr)
-	response, err := command.Run(sourceDir, request)
+	ctx, cancel := resource.NewContext(request.Source)
+	if err := resource.CheckAccessToken(ctx, gitlab, request.Source, os.Stderr, "api"); err != nil {
+		cancel()
+		resource.Fatal("checking access token", err)
+	}
+	if err := resource.ResolveProject(ctx, gitlab, request.Source, os.Stderr); err != nil {
+		cancel()
+		resource.Fatal("resolving repository", err)
+	}
+	response, err := command.Run(ctx, sourceDir, request)
+	cancel()
 	if err != nil {
 		resource.Fatal("running command", err)
 	}
@@ -0,0 +1,25 @@
+package resource
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// NewContext returns the context of a resource run. It is cancelled when
+// Concourse aborts the build, which sends SIGTERM or SIGINT, and once the
+// overall timeout of the source expires.
+func NewContext(source Source) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	if source.Timeout == 0 {
+		return ctx, stop
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(source.Timeout))
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
@@ -0,0 +1,15 @@
+//go:build unix
+
+package resource
+
+import "syscall"
+
+// freeDiskSpace returns the space available to unprivileged users on the
+// filesystem holding dir.
+func freeDiskSpace(dir string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return -1, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
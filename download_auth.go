@@ -0,0 +1,170 @@
+package resource
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+type downloadAuth struct {
+	DownloadAuth
+
+	host        string
+	pathPrefix  string
+	certificate *tls.Certificate
+	proxy       *url.URL
+}
+
+func newDownloadAuths(source Source) ([]*downloadAuth, error) {
+	entries := append([]DownloadAuth{}, source.DownloadAuths...)
+	if storage := source.S3; storage != nil && storage.AccessKeyID != "" {
+		// the bucket of upload_to: s3 is signed with its credentials
+		endpoint, err := url.Parse(storage.Endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid s3 endpoint: %s", err)
+		}
+		credentials := storage.S3Credentials
+		entries = append(entries, DownloadAuth{
+			Host:       endpoint.Hostname(),
+			PathPrefix: path.Join(endpoint.Path, storage.Bucket),
+			S3:         &credentials,
+		})
+	}
+	if source.Netrc != "" {
+		netrc, err := parseNetrc(source.Netrc)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, netrc...)
+	}
+
+	auths := []*downloadAuth{}
+	for _, entry := range entries {
+		if entry.Host == "" {
+			return nil, fmt.Errorf("missing host in download_auths")
+		}
+		auth := &downloadAuth{
+			DownloadAuth: entry,
+			host:         strings.ToLower(entry.Host),
+			pathPrefix:   "/" + strings.Trim(entry.PathPrefix, "/"),
+		}
+
+		certificate, err := parseClientCertificate(entry.ClientCert, entry.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate for download host '%s': %s", entry.Host, err)
+		}
+		auth.certificate = certificate
+		auth.proxy, err = parseProxyURL("proxy_url for download host '"+entry.Host+"'", entry.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		auths = append(auths, auth)
+	}
+
+	// most specific first, configured entries before netrc ones on ties
+	sort.SliceStable(auths, func(i, j int) bool {
+		hi, hj := auths[i].hostSpecificity(), auths[j].hostSpecificity()
+		if hi != hj {
+			return hi > hj
+		}
+		return len(auths[i].pathPrefix) > len(auths[j].pathPrefix)
+	})
+	return auths, nil
+}
+
+// hostSpecificity ranks exact hosts above wildcards, and longer wildcard
+// suffixes above shorter ones.
+func (a *downloadAuth) hostSpecificity() int {
+	switch {
+	case a.host == "*":
+		return 0
+	case strings.HasPrefix(a.host, "*."):
+		return len(a.host)
+	}
+	return 1 << 16
+}
+
+func (a *downloadAuth) matches(u *url.URL) bool {
+	if !matchHost(a.host, u.Hostname()) {
+		return false
+	}
+
+	if a.pathPrefix == "/" {
+		return true
+	}
+	p := u.EscapedPath()
+	return p == a.pathPrefix || strings.HasPrefix(p, a.pathPrefix+"/")
+}
+
+func (a *downloadAuth) apply(req *http.Request) {
+	switch {
+	case a.Token != "":
+		req.Header.Set("Authorization", "Bearer "+a.Token)
+	case a.Username != "" || a.Password != "":
+		req.SetBasicAuth(a.Username, a.Password)
+	}
+	for name, value := range a.Headers {
+		req.Header.Set(name, value)
+	}
+	// signed last, the signature covering the headers
+	if a.S3 != nil {
+		signV4(req, a.S3, time.Now())
+	}
+}
+
+// findDownloadAuth returns the most specific credentials for the URL, if any.
+func findDownloadAuth(auths []*downloadAuth, u *url.URL) *downloadAuth {
+	for _, auth := range auths {
+		if auth.matches(u) {
+			return auth
+		}
+	}
+	return nil
+}
+
+// parseNetrc reads the `machine` entries of a .netrc file as basic auth
+// credentials. `default` entries are ignored so that credentials are never
+// sent to unknown hosts.
+func parseNetrc(content string) ([]DownloadAuth, error) {
+	auths := []DownloadAuth{}
+	var current *DownloadAuth
+	tokens := strings.Fields(content)
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "machine", "login", "password", "account":
+			if i+1 >= len(tokens) {
+				return nil, fmt.Errorf("invalid netrc: missing value for '%s'", tokens[i])
+			}
+		}
+
+		switch tokens[i] {
+		case "machine":
+			auths = append(auths, DownloadAuth{Host: tokens[i+1]})
+			current = &auths[len(auths)-1]
+			i++
+		case "default":
+			current = nil
+		case "login":
+			if current != nil {
+				current.Username = tokens[i+1]
+			}
+			i++
+		case "password":
+			if current != nil {
+				current.Password = tokens[i+1]
+			}
+			i++
+		case "account":
+			i++
+		case "macdef":
+			// macros run until an empty line, which Fields cannot see
+			return nil, fmt.Errorf("invalid netrc: macdef is not supported")
+		}
+	}
+	return auths, nil
+}
@@ -0,0 +1,102 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+var (
+	ErrNotFound     = errors.New("object not found")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrConflict     = errors.New("conflict")
+	ErrRateLimited  = errors.New("rate limited")
+)
+
+// RequestError reports a request GitLab or an asset host answered with an
+// error status. It matches the error of its status class with errors.Is, e.g.
+// ErrForbidden for a 403.
+type RequestError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Message    string
+}
+
+func newRequestError(resp *http.Response, message string) *RequestError {
+	e := &RequestError{
+		StatusCode: resp.StatusCode,
+		Message:    message,
+	}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.URL = redactURL(resp.Request.URL)
+	}
+	return e
+}
+
+// Error leaves the request out, callers already describe it.
+func (e *RequestError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("HTTP status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("HTTP status %d: %s", e.StatusCode, e.Message)
+}
+
+func (e *RequestError) Unwrap() error {
+	return statusError(e.StatusCode)
+}
+
+// statusError returns the error of the status class, or nil for statuses
+// without one.
+func statusError(status int) error {
+	switch status {
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusConflict:
+		return ErrConflict
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	}
+	return nil
+}
+
+// apiError converts the error of an API call answered with an error status
+// into a *RequestError, prefixed with the request. Other errors are returned
+// unchanged.
+func apiError(resp *gitlab.Response, err error) error {
+	if err == nil || resp == nil || resp.Response == nil || resp.StatusCode < http.StatusBadRequest {
+		return err
+	}
+	message := ""
+	var errResp *gitlab.ErrorResponse
+	if errors.As(err, &errResp) {
+		message = errResp.Message
+	}
+	reqErr := newRequestError(resp.Response, message)
+	return fmt.Errorf("%s %s: %w", reqErr.Method, reqErr.URL, reqErr)
+}
+
+// errorHint suggests how to fix the cause of err, if known.
+func errorHint(err error) string {
+	switch {
+	case errors.Is(err, ErrUnauthorized):
+		return "the access token is missing, invalid, expired or revoked: check access_token"
+	case errors.Is(err, ErrForbidden):
+		return "the access token lacks permissions: it needs the api scope and at least the Developer role on the repository, Maintainer for protected tags"
+	case errors.Is(err, ErrNotFound):
+		return "the object does not exist or is hidden from the access token: check repository, gitlab_api_url and the token permissions"
+	case errors.Is(err, ErrConflict):
+		return "the object already exists, it was likely created concurrently: retry the build"
+	case errors.Is(err, ErrRateLimited):
+		return "GitLab is rate limiting requests: retry later, or raise max_retries and retry_wait_max"
+	}
+	return ""
+}
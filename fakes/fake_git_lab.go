@@ -2,6 +2,7 @@
 package fakes
 
 import (
+	"context"
 	"sync"
 
 	resource "github.com/orange-cloudfoundry/gitlab-release-resource"
@@ -9,12 +10,28 @@ import (
 )
 
 type FakeGitLab struct {
-	CreateReleaseStub        func(string, string, *string) (*gitlab.Release, error)
+	CompareRefsStub        func(context.Context, string, string) (*gitlab.Compare, error)
+	compareRefsMutex       sync.RWMutex
+	compareRefsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	compareRefsReturns struct {
+		result1 *gitlab.Compare
+		result2 error
+	}
+	compareRefsReturnsOnCall map[int]struct {
+		result1 *gitlab.Compare
+		result2 error
+	}
+	CreateReleaseStub        func(context.Context, string, string, *string) (*gitlab.Release, error)
 	createReleaseMutex       sync.RWMutex
 	createReleaseArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-		arg3 *string
+		arg3 string
+		arg4 *string
 	}
 	createReleaseReturns struct {
 		result1 *gitlab.Release
@@ -24,12 +41,12 @@ type FakeGitLab struct {
 		result1 *gitlab.Release
 		result2 error
 	}
-	CreateReleaseLinkStub        func(string, string, string) (*gitlab.ReleaseLink, error)
+	CreateReleaseLinkStub        func(context.Context, string, resource.ReleaseLinkSpec) (*gitlab.ReleaseLink, error)
 	createReleaseLinkMutex       sync.RWMutex
 	createReleaseLinkArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-		arg3 string
+		arg3 resource.ReleaseLinkSpec
 	}
 	createReleaseLinkReturns struct {
 		result1 *gitlab.ReleaseLink
@@ -39,11 +56,12 @@ type FakeGitLab struct {
 		result1 *gitlab.ReleaseLink
 		result2 error
 	}
-	CreateTagStub        func(string, string) (*gitlab.Tag, error)
+	CreateTagStub        func(context.Context, string, string) (*gitlab.Tag, error)
 	createTagMutex       sync.RWMutex
 	createTagArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
+		arg3 string
 	}
 	createTagReturns struct {
 		result1 *gitlab.Tag
@@ -53,11 +71,24 @@ type FakeGitLab struct {
 		result1 *gitlab.Tag
 		result2 error
 	}
-	DeleteReleaseLinkStub        func(string, *gitlab.ReleaseLink) error
+	DeleteReleaseStub        func(context.Context, string) error
+	deleteReleaseMutex       sync.RWMutex
+	deleteReleaseArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	deleteReleaseReturns struct {
+		result1 error
+	}
+	deleteReleaseReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteReleaseLinkStub        func(context.Context, string, *gitlab.ReleaseLink) error
 	deleteReleaseLinkMutex       sync.RWMutex
 	deleteReleaseLinkArgsForCall []struct {
-		arg1 string
-		arg2 *gitlab.ReleaseLink
+		arg1 context.Context
+		arg2 string
+		arg3 *gitlab.ReleaseLink
 	}
 	deleteReleaseLinkReturns struct {
 		result1 error
@@ -65,22 +96,142 @@ type FakeGitLab struct {
 	deleteReleaseLinkReturnsOnCall map[int]struct {
 		result1 error
 	}
-	DownloadProjectFileStub        func(string, string) error
+	DeleteTagStub        func(context.Context, string) error
+	deleteTagMutex       sync.RWMutex
+	deleteTagArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	deleteTagReturns struct {
+		result1 error
+	}
+	deleteTagReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DownloadArchiveStub        func(context.Context, string, string, string, string) error
+	downloadArchiveMutex       sync.RWMutex
+	downloadArchiveArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 string
+	}
+	downloadArchiveReturns struct {
+		result1 error
+	}
+	downloadArchiveReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DownloadProjectFileStub        func(context.Context, string, string, int64) (int64, error)
 	downloadProjectFileMutex       sync.RWMutex
 	downloadProjectFileArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
+		arg3 string
+		arg4 int64
 	}
 	downloadProjectFileReturns struct {
-		result1 error
+		result1 int64
+		result2 error
 	}
 	downloadProjectFileReturnsOnCall map[int]struct {
+		result1 int64
+		result2 error
+	}
+	DownloadProjectFileIfModifiedStub        func(context.Context, string, string, int64, resource.FileValidator) (resource.FileValidator, int64, error)
+	downloadProjectFileIfModifiedMutex       sync.RWMutex
+	downloadProjectFileIfModifiedArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 int64
+		arg5 resource.FileValidator
+	}
+	downloadProjectFileIfModifiedReturns struct {
+		result1 resource.FileValidator
+		result2 int64
+		result3 error
+	}
+	downloadProjectFileIfModifiedReturnsOnCall map[int]struct {
+		result1 resource.FileValidator
+		result2 int64
+		result3 error
+	}
+	DownloadRepositoryFileStub        func(context.Context, string, string, string) error
+	downloadRepositoryFileMutex       sync.RWMutex
+	downloadRepositoryFileArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+	}
+	downloadRepositoryFileReturns struct {
 		result1 error
 	}
-	GetReleaseStub        func(string) (*gitlab.Release, error)
+	downloadRepositoryFileReturnsOnCall map[int]struct {
+		result1 error
+	}
+	GetAccessTokenStub        func(context.Context) (*gitlab.PersonalAccessToken, error)
+	getAccessTokenMutex       sync.RWMutex
+	getAccessTokenArgsForCall []struct {
+		arg1 context.Context
+	}
+	getAccessTokenReturns struct {
+		result1 *gitlab.PersonalAccessToken
+		result2 error
+	}
+	getAccessTokenReturnsOnCall map[int]struct {
+		result1 *gitlab.PersonalAccessToken
+		result2 error
+	}
+	GetProjectStub        func(context.Context) (*gitlab.Project, error)
+	getProjectMutex       sync.RWMutex
+	getProjectArgsForCall []struct {
+		arg1 context.Context
+	}
+	getProjectReturns struct {
+		result1 *gitlab.Project
+		result2 error
+	}
+	getProjectReturnsOnCall map[int]struct {
+		result1 *gitlab.Project
+		result2 error
+	}
+	GetProjectFileSizeStub        func(context.Context, string) (int64, error)
+	getProjectFileSizeMutex       sync.RWMutex
+	getProjectFileSizeArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	getProjectFileSizeReturns struct {
+		result1 int64
+		result2 error
+	}
+	getProjectFileSizeReturnsOnCall map[int]struct {
+		result1 int64
+		result2 error
+	}
+	GetRegistryRepositoryTagStub        func(context.Context, int64, string) (*gitlab.RegistryRepositoryTag, error)
+	getRegistryRepositoryTagMutex       sync.RWMutex
+	getRegistryRepositoryTagArgsForCall []struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 string
+	}
+	getRegistryRepositoryTagReturns struct {
+		result1 *gitlab.RegistryRepositoryTag
+		result2 error
+	}
+	getRegistryRepositoryTagReturnsOnCall map[int]struct {
+		result1 *gitlab.RegistryRepositoryTag
+		result2 error
+	}
+	GetReleaseStub        func(context.Context, string) (*gitlab.Release, error)
 	getReleaseMutex       sync.RWMutex
 	getReleaseArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
+		arg2 string
 	}
 	getReleaseReturns struct {
 		result1 *gitlab.Release
@@ -90,10 +241,11 @@ type FakeGitLab struct {
 		result1 *gitlab.Release
 		result2 error
 	}
-	GetReleaseLinksStub        func(string) ([]*gitlab.ReleaseLink, error)
+	GetReleaseLinksStub        func(context.Context, string) ([]*gitlab.ReleaseLink, error)
 	getReleaseLinksMutex       sync.RWMutex
 	getReleaseLinksArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
+		arg2 string
 	}
 	getReleaseLinksReturns struct {
 		result1 []*gitlab.ReleaseLink
@@ -103,10 +255,11 @@ type FakeGitLab struct {
 		result1 []*gitlab.ReleaseLink
 		result2 error
 	}
-	GetTagStub        func(string) (*gitlab.Tag, error)
+	GetTagStub        func(context.Context, string) (*gitlab.Tag, error)
 	getTagMutex       sync.RWMutex
 	getTagArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
+		arg2 string
 	}
 	getTagReturns struct {
 		result1 *gitlab.Tag
@@ -116,9 +269,37 @@ type FakeGitLab struct {
 		result1 *gitlab.Tag
 		result2 error
 	}
-	ListReleasesStub        func() ([]*gitlab.Release, error)
+	ListMergeRequestsByCommitStub        func(context.Context, string) ([]*gitlab.BasicMergeRequest, error)
+	listMergeRequestsByCommitMutex       sync.RWMutex
+	listMergeRequestsByCommitArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	listMergeRequestsByCommitReturns struct {
+		result1 []*gitlab.BasicMergeRequest
+		result2 error
+	}
+	listMergeRequestsByCommitReturnsOnCall map[int]struct {
+		result1 []*gitlab.BasicMergeRequest
+		result2 error
+	}
+	ListRegistryRepositoriesStub        func(context.Context) ([]*gitlab.RegistryRepository, error)
+	listRegistryRepositoriesMutex       sync.RWMutex
+	listRegistryRepositoriesArgsForCall []struct {
+		arg1 context.Context
+	}
+	listRegistryRepositoriesReturns struct {
+		result1 []*gitlab.RegistryRepository
+		result2 error
+	}
+	listRegistryRepositoriesReturnsOnCall map[int]struct {
+		result1 []*gitlab.RegistryRepository
+		result2 error
+	}
+	ListReleasesStub        func(context.Context) ([]*gitlab.Release, error)
 	listReleasesMutex       sync.RWMutex
 	listReleasesArgsForCall []struct {
+		arg1 context.Context
 	}
 	listReleasesReturns struct {
 		result1 []*gitlab.Release
@@ -128,9 +309,24 @@ type FakeGitLab struct {
 		result1 []*gitlab.Release
 		result2 error
 	}
-	ListTagsStub        func() ([]*gitlab.Tag, error)
+	ListRepositoryTreeStub        func(context.Context, string) ([]*gitlab.TreeNode, error)
+	listRepositoryTreeMutex       sync.RWMutex
+	listRepositoryTreeArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	listRepositoryTreeReturns struct {
+		result1 []*gitlab.TreeNode
+		result2 error
+	}
+	listRepositoryTreeReturnsOnCall map[int]struct {
+		result1 []*gitlab.TreeNode
+		result2 error
+	}
+	ListTagsStub        func(context.Context) ([]*gitlab.Tag, error)
 	listTagsMutex       sync.RWMutex
 	listTagsArgsForCall []struct {
+		arg1 context.Context
 	}
 	listTagsReturns struct {
 		result1 []*gitlab.Tag
@@ -140,10 +336,11 @@ type FakeGitLab struct {
 		result1 []*gitlab.Tag
 		result2 error
 	}
-	ListTagsUntilStub        func(string) ([]*gitlab.Tag, error)
+	ListTagsUntilStub        func(context.Context, string) ([]*gitlab.Tag, error)
 	listTagsUntilMutex       sync.RWMutex
 	listTagsUntilArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
+		arg2 string
 	}
 	listTagsUntilReturns struct {
 		result1 []*gitlab.Tag
@@ -153,12 +350,29 @@ type FakeGitLab struct {
 		result1 []*gitlab.Tag
 		result2 error
 	}
-	UpdateReleaseStub        func(string, string, *string) (*gitlab.Release, error)
+	PublishPackageFileStub        func(context.Context, string, string, string) (string, error)
+	publishPackageFileMutex       sync.RWMutex
+	publishPackageFileArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+	}
+	publishPackageFileReturns struct {
+		result1 string
+		result2 error
+	}
+	publishPackageFileReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	UpdateReleaseStub        func(context.Context, string, string, *string) (*gitlab.Release, error)
 	updateReleaseMutex       sync.RWMutex
 	updateReleaseArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-		arg3 *string
+		arg3 string
+		arg4 *string
 	}
 	updateReleaseReturns struct {
 		result1 *gitlab.Release
@@ -168,10 +382,40 @@ type FakeGitLab struct {
 		result1 *gitlab.Release
 		result2 error
 	}
-	UploadProjectFileStub        func(string) (*gitlab.ProjectMarkdownUploadedFile, error)
+	UpdateReleaseLinkStub        func(context.Context, string, *gitlab.ReleaseLink, resource.ReleaseLinkSpec) (*gitlab.ReleaseLink, error)
+	updateReleaseLinkMutex       sync.RWMutex
+	updateReleaseLinkArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 *gitlab.ReleaseLink
+		arg4 resource.ReleaseLinkSpec
+	}
+	updateReleaseLinkReturns struct {
+		result1 *gitlab.ReleaseLink
+		result2 error
+	}
+	updateReleaseLinkReturnsOnCall map[int]struct {
+		result1 *gitlab.ReleaseLink
+		result2 error
+	}
+	UploadObjectStub        func(context.Context, string, string) error
+	uploadObjectMutex       sync.RWMutex
+	uploadObjectArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	uploadObjectReturns struct {
+		result1 error
+	}
+	uploadObjectReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UploadProjectFileStub        func(context.Context, string) (*gitlab.ProjectMarkdownUploadedFile, error)
 	uploadProjectFileMutex       sync.RWMutex
 	uploadProjectFileArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
+		arg2 string
 	}
 	uploadProjectFileReturns struct {
 		result1 *gitlab.ProjectMarkdownUploadedFile
@@ -185,20 +429,87 @@ type FakeGitLab struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeGitLab) CreateRelease(arg1 string, arg2 string, arg3 *string) (*gitlab.Release, error) {
+func (fake *FakeGitLab) CompareRefs(arg1 context.Context, arg2 string, arg3 string) (*gitlab.Compare, error) {
+	fake.compareRefsMutex.Lock()
+	ret, specificReturn := fake.compareRefsReturnsOnCall[len(fake.compareRefsArgsForCall)]
+	fake.compareRefsArgsForCall = append(fake.compareRefsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.CompareRefsStub
+	fakeReturns := fake.compareRefsReturns
+	fake.recordInvocation("CompareRefs", []interface{}{arg1, arg2, arg3})
+	fake.compareRefsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGitLab) CompareRefsCallCount() int {
+	fake.compareRefsMutex.RLock()
+	defer fake.compareRefsMutex.RUnlock()
+	return len(fake.compareRefsArgsForCall)
+}
+
+func (fake *FakeGitLab) CompareRefsCalls(stub func(context.Context, string, string) (*gitlab.Compare, error)) {
+	fake.compareRefsMutex.Lock()
+	defer fake.compareRefsMutex.Unlock()
+	fake.CompareRefsStub = stub
+}
+
+func (fake *FakeGitLab) CompareRefsArgsForCall(i int) (context.Context, string, string) {
+	fake.compareRefsMutex.RLock()
+	defer fake.compareRefsMutex.RUnlock()
+	argsForCall := fake.compareRefsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeGitLab) CompareRefsReturns(result1 *gitlab.Compare, result2 error) {
+	fake.compareRefsMutex.Lock()
+	defer fake.compareRefsMutex.Unlock()
+	fake.CompareRefsStub = nil
+	fake.compareRefsReturns = struct {
+		result1 *gitlab.Compare
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGitLab) CompareRefsReturnsOnCall(i int, result1 *gitlab.Compare, result2 error) {
+	fake.compareRefsMutex.Lock()
+	defer fake.compareRefsMutex.Unlock()
+	fake.CompareRefsStub = nil
+	if fake.compareRefsReturnsOnCall == nil {
+		fake.compareRefsReturnsOnCall = make(map[int]struct {
+			result1 *gitlab.Compare
+			result2 error
+		})
+	}
+	fake.compareRefsReturnsOnCall[i] = struct {
+		result1 *gitlab.Compare
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGitLab) CreateRelease(arg1 context.Context, arg2 string, arg3 string, arg4 *string) (*gitlab.Release, error) {
 	fake.createReleaseMutex.Lock()
 	ret, specificReturn := fake.createReleaseReturnsOnCall[len(fake.createReleaseArgsForCall)]
 	fake.createReleaseArgsForCall = append(fake.createReleaseArgsForCall, struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-		arg3 *string
-	}{arg1, arg2, arg3})
+		arg3 string
+		arg4 *string
+	}{arg1, arg2, arg3, arg4})
 	stub := fake.CreateReleaseStub
 	fakeReturns := fake.createReleaseReturns
-	fake.recordInvocation("CreateRelease", []interface{}{arg1, arg2, arg3})
+	fake.recordInvocation("CreateRelease", []interface{}{arg1, arg2, arg3, arg4})
 	fake.createReleaseMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2, arg3)
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -212,17 +523,17 @@ func (fake *FakeGitLab) CreateReleaseCallCount() int {
 	return len(fake.createReleaseArgsForCall)
 }
 
-func (fake *FakeGitLab) CreateReleaseCalls(stub func(string, string, *string) (*gitlab.Release, error)) {
+func (fake *FakeGitLab) CreateReleaseCalls(stub func(context.Context, string, string, *string) (*gitlab.Release, error)) {
 	fake.createReleaseMutex.Lock()
 	defer fake.createReleaseMutex.Unlock()
 	fake.CreateReleaseStub = stub
 }
 
-func (fake *FakeGitLab) CreateReleaseArgsForCall(i int) (string, string, *string) {
+func (fake *FakeGitLab) CreateReleaseArgsForCall(i int) (context.Context, string, string, *string) {
 	fake.createReleaseMutex.RLock()
 	defer fake.createReleaseMutex.RUnlock()
 	argsForCall := fake.createReleaseArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
 func (fake *FakeGitLab) CreateReleaseReturns(result1 *gitlab.Release, result2 error) {
@@ -251,13 +562,13 @@ func (fake *FakeGitLab) CreateReleaseReturnsOnCall(i int, result1 *gitlab.Releas
 	}{result1, result2}
 }
 
-func (fake *FakeGitLab) CreateReleaseLink(arg1 string, arg2 string, arg3 string) (*gitlab.ReleaseLink, error) {
+func (fake *FakeGitLab) CreateReleaseLink(arg1 context.Context, arg2 string, arg3 resource.ReleaseLinkSpec) (*gitlab.ReleaseLink, error) {
 	fake.createReleaseLinkMutex.Lock()
 	ret, specificReturn := fake.createReleaseLinkReturnsOnCall[len(fake.createReleaseLinkArgsForCall)]
 	fake.createReleaseLinkArgsForCall = append(fake.createReleaseLinkArgsForCall, struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-		arg3 string
+		arg3 resource.ReleaseLinkSpec
 	}{arg1, arg2, arg3})
 	stub := fake.CreateReleaseLinkStub
 	fakeReturns := fake.createReleaseLinkReturns
@@ -278,13 +589,13 @@ func (fake *FakeGitLab) CreateReleaseLinkCallCount() int {
 	return len(fake.createReleaseLinkArgsForCall)
 }
 
-func (fake *FakeGitLab) CreateReleaseLinkCalls(stub func(string, string, string) (*gitlab.ReleaseLink, error)) {
+func (fake *FakeGitLab) CreateReleaseLinkCalls(stub func(context.Context, string, resource.ReleaseLinkSpec) (*gitlab.ReleaseLink, error)) {
 	fake.createReleaseLinkMutex.Lock()
 	defer fake.createReleaseLinkMutex.Unlock()
 	fake.CreateReleaseLinkStub = stub
 }
 
-func (fake *FakeGitLab) CreateReleaseLinkArgsForCall(i int) (string, string, string) {
+func (fake *FakeGitLab) CreateReleaseLinkArgsForCall(i int) (context.Context, string, resource.ReleaseLinkSpec) {
 	fake.createReleaseLinkMutex.RLock()
 	defer fake.createReleaseLinkMutex.RUnlock()
 	argsForCall := fake.createReleaseLinkArgsForCall[i]
@@ -317,19 +628,20 @@ func (fake *FakeGitLab) CreateReleaseLinkReturnsOnCall(i int, result1 *gitlab.Re
 	}{result1, result2}
 }
 
-func (fake *FakeGitLab) CreateTag(arg1 string, arg2 string) (*gitlab.Tag, error) {
+func (fake *FakeGitLab) CreateTag(arg1 context.Context, arg2 string, arg3 string) (*gitlab.Tag, error) {
 	fake.createTagMutex.Lock()
 	ret, specificReturn := fake.createTagReturnsOnCall[len(fake.createTagArgsForCall)]
 	fake.createTagArgsForCall = append(fake.createTagArgsForCall, struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-	}{arg1, arg2})
+		arg3 string
+	}{arg1, arg2, arg3})
 	stub := fake.CreateTagStub
 	fakeReturns := fake.createTagReturns
-	fake.recordInvocation("CreateTag", []interface{}{arg1, arg2})
+	fake.recordInvocation("CreateTag", []interface{}{arg1, arg2, arg3})
 	fake.createTagMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -343,17 +655,17 @@ func (fake *FakeGitLab) CreateTagCallCount() int {
 	return len(fake.createTagArgsForCall)
 }
 
-func (fake *FakeGitLab) CreateTagCalls(stub func(string, string) (*gitlab.Tag, error)) {
+func (fake *FakeGitLab) CreateTagCalls(stub func(context.Context, string, string) (*gitlab.Tag, error)) {
 	fake.createTagMutex.Lock()
 	defer fake.createTagMutex.Unlock()
 	fake.CreateTagStub = stub
 }
 
-func (fake *FakeGitLab) CreateTagArgsForCall(i int) (string, string) {
+func (fake *FakeGitLab) CreateTagArgsForCall(i int) (context.Context, string, string) {
 	fake.createTagMutex.RLock()
 	defer fake.createTagMutex.RUnlock()
 	argsForCall := fake.createTagArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeGitLab) CreateTagReturns(result1 *gitlab.Tag, result2 error) {
@@ -382,19 +694,82 @@ func (fake *FakeGitLab) CreateTagReturnsOnCall(i int, result1 *gitlab.Tag, resul
 	}{result1, result2}
 }
 
-func (fake *FakeGitLab) DeleteReleaseLink(arg1 string, arg2 *gitlab.ReleaseLink) error {
+func (fake *FakeGitLab) DeleteRelease(arg1 context.Context, arg2 string) error {
+	fake.deleteReleaseMutex.Lock()
+	ret, specificReturn := fake.deleteReleaseReturnsOnCall[len(fake.deleteReleaseArgsForCall)]
+	fake.deleteReleaseArgsForCall = append(fake.deleteReleaseArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.DeleteReleaseStub
+	fakeReturns := fake.deleteReleaseReturns
+	fake.recordInvocation("DeleteRelease", []interface{}{arg1, arg2})
+	fake.deleteReleaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeGitLab) DeleteReleaseCallCount() int {
+	fake.deleteReleaseMutex.RLock()
+	defer fake.deleteReleaseMutex.RUnlock()
+	return len(fake.deleteReleaseArgsForCall)
+}
+
+func (fake *FakeGitLab) DeleteReleaseCalls(stub func(context.Context, string) error) {
+	fake.deleteReleaseMutex.Lock()
+	defer fake.deleteReleaseMutex.Unlock()
+	fake.DeleteReleaseStub = stub
+}
+
+func (fake *FakeGitLab) DeleteReleaseArgsForCall(i int) (context.Context, string) {
+	fake.deleteReleaseMutex.RLock()
+	defer fake.deleteReleaseMutex.RUnlock()
+	argsForCall := fake.deleteReleaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGitLab) DeleteReleaseReturns(result1 error) {
+	fake.deleteReleaseMutex.Lock()
+	defer fake.deleteReleaseMutex.Unlock()
+	fake.DeleteReleaseStub = nil
+	fake.deleteReleaseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGitLab) DeleteReleaseReturnsOnCall(i int, result1 error) {
+	fake.deleteReleaseMutex.Lock()
+	defer fake.deleteReleaseMutex.Unlock()
+	fake.DeleteReleaseStub = nil
+	if fake.deleteReleaseReturnsOnCall == nil {
+		fake.deleteReleaseReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteReleaseReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGitLab) DeleteReleaseLink(arg1 context.Context, arg2 string, arg3 *gitlab.ReleaseLink) error {
 	fake.deleteReleaseLinkMutex.Lock()
 	ret, specificReturn := fake.deleteReleaseLinkReturnsOnCall[len(fake.deleteReleaseLinkArgsForCall)]
 	fake.deleteReleaseLinkArgsForCall = append(fake.deleteReleaseLinkArgsForCall, struct {
-		arg1 string
-		arg2 *gitlab.ReleaseLink
-	}{arg1, arg2})
+		arg1 context.Context
+		arg2 string
+		arg3 *gitlab.ReleaseLink
+	}{arg1, arg2, arg3})
 	stub := fake.DeleteReleaseLinkStub
 	fakeReturns := fake.deleteReleaseLinkReturns
-	fake.recordInvocation("DeleteReleaseLink", []interface{}{arg1, arg2})
+	fake.recordInvocation("DeleteReleaseLink", []interface{}{arg1, arg2, arg3})
 	fake.deleteReleaseLinkMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1
@@ -408,17 +783,17 @@ func (fake *FakeGitLab) DeleteReleaseLinkCallCount() int {
 	return len(fake.deleteReleaseLinkArgsForCall)
 }
 
-func (fake *FakeGitLab) DeleteReleaseLinkCalls(stub func(string, *gitlab.ReleaseLink) error) {
+func (fake *FakeGitLab) DeleteReleaseLinkCalls(stub func(context.Context, string, *gitlab.ReleaseLink) error) {
 	fake.deleteReleaseLinkMutex.Lock()
 	defer fake.deleteReleaseLinkMutex.Unlock()
 	fake.DeleteReleaseLinkStub = stub
 }
 
-func (fake *FakeGitLab) DeleteReleaseLinkArgsForCall(i int) (string, *gitlab.ReleaseLink) {
+func (fake *FakeGitLab) DeleteReleaseLinkArgsForCall(i int) (context.Context, string, *gitlab.ReleaseLink) {
 	fake.deleteReleaseLinkMutex.RLock()
 	defer fake.deleteReleaseLinkMutex.RUnlock()
 	argsForCall := fake.deleteReleaseLinkArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeGitLab) DeleteReleaseLinkReturns(result1 error) {
@@ -444,24 +819,153 @@ func (fake *FakeGitLab) DeleteReleaseLinkReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeGitLab) DownloadProjectFile(arg1 string, arg2 string) error {
+func (fake *FakeGitLab) DeleteTag(arg1 context.Context, arg2 string) error {
+	fake.deleteTagMutex.Lock()
+	ret, specificReturn := fake.deleteTagReturnsOnCall[len(fake.deleteTagArgsForCall)]
+	fake.deleteTagArgsForCall = append(fake.deleteTagArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.DeleteTagStub
+	fakeReturns := fake.deleteTagReturns
+	fake.recordInvocation("DeleteTag", []interface{}{arg1, arg2})
+	fake.deleteTagMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeGitLab) DeleteTagCallCount() int {
+	fake.deleteTagMutex.RLock()
+	defer fake.deleteTagMutex.RUnlock()
+	return len(fake.deleteTagArgsForCall)
+}
+
+func (fake *FakeGitLab) DeleteTagCalls(stub func(context.Context, string) error) {
+	fake.deleteTagMutex.Lock()
+	defer fake.deleteTagMutex.Unlock()
+	fake.DeleteTagStub = stub
+}
+
+func (fake *FakeGitLab) DeleteTagArgsForCall(i int) (context.Context, string) {
+	fake.deleteTagMutex.RLock()
+	defer fake.deleteTagMutex.RUnlock()
+	argsForCall := fake.deleteTagArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGitLab) DeleteTagReturns(result1 error) {
+	fake.deleteTagMutex.Lock()
+	defer fake.deleteTagMutex.Unlock()
+	fake.DeleteTagStub = nil
+	fake.deleteTagReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGitLab) DeleteTagReturnsOnCall(i int, result1 error) {
+	fake.deleteTagMutex.Lock()
+	defer fake.deleteTagMutex.Unlock()
+	fake.DeleteTagStub = nil
+	if fake.deleteTagReturnsOnCall == nil {
+		fake.deleteTagReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteTagReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGitLab) DownloadArchive(arg1 context.Context, arg2 string, arg3 string, arg4 string, arg5 string) error {
+	fake.downloadArchiveMutex.Lock()
+	ret, specificReturn := fake.downloadArchiveReturnsOnCall[len(fake.downloadArchiveArgsForCall)]
+	fake.downloadArchiveArgsForCall = append(fake.downloadArchiveArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 string
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.DownloadArchiveStub
+	fakeReturns := fake.downloadArchiveReturns
+	fake.recordInvocation("DownloadArchive", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.downloadArchiveMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeGitLab) DownloadArchiveCallCount() int {
+	fake.downloadArchiveMutex.RLock()
+	defer fake.downloadArchiveMutex.RUnlock()
+	return len(fake.downloadArchiveArgsForCall)
+}
+
+func (fake *FakeGitLab) DownloadArchiveCalls(stub func(context.Context, string, string, string, string) error) {
+	fake.downloadArchiveMutex.Lock()
+	defer fake.downloadArchiveMutex.Unlock()
+	fake.DownloadArchiveStub = stub
+}
+
+func (fake *FakeGitLab) DownloadArchiveArgsForCall(i int) (context.Context, string, string, string, string) {
+	fake.downloadArchiveMutex.RLock()
+	defer fake.downloadArchiveMutex.RUnlock()
+	argsForCall := fake.downloadArchiveArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeGitLab) DownloadArchiveReturns(result1 error) {
+	fake.downloadArchiveMutex.Lock()
+	defer fake.downloadArchiveMutex.Unlock()
+	fake.DownloadArchiveStub = nil
+	fake.downloadArchiveReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGitLab) DownloadArchiveReturnsOnCall(i int, result1 error) {
+	fake.downloadArchiveMutex.Lock()
+	defer fake.downloadArchiveMutex.Unlock()
+	fake.DownloadArchiveStub = nil
+	if fake.downloadArchiveReturnsOnCall == nil {
+		fake.downloadArchiveReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.downloadArchiveReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGitLab) DownloadProjectFile(arg1 context.Context, arg2 string, arg3 string, arg4 int64) (int64, error) {
 	fake.downloadProjectFileMutex.Lock()
 	ret, specificReturn := fake.downloadProjectFileReturnsOnCall[len(fake.downloadProjectFileArgsForCall)]
 	fake.downloadProjectFileArgsForCall = append(fake.downloadProjectFileArgsForCall, struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-	}{arg1, arg2})
+		arg3 string
+		arg4 int64
+	}{arg1, arg2, arg3, arg4})
 	stub := fake.DownloadProjectFileStub
 	fakeReturns := fake.downloadProjectFileReturns
-	fake.recordInvocation("DownloadProjectFile", []interface{}{arg1, arg2})
+	fake.recordInvocation("DownloadProjectFile", []interface{}{arg1, arg2, arg3, arg4})
 	fake.downloadProjectFileMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
-		return ret.result1
+		return ret.result1, ret.result2
 	}
-	return fakeReturns.result1
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *FakeGitLab) DownloadProjectFileCallCount() int {
@@ -470,54 +974,452 @@ func (fake *FakeGitLab) DownloadProjectFileCallCount() int {
 	return len(fake.downloadProjectFileArgsForCall)
 }
 
-func (fake *FakeGitLab) DownloadProjectFileCalls(stub func(string, string) error) {
+func (fake *FakeGitLab) DownloadProjectFileCalls(stub func(context.Context, string, string, int64) (int64, error)) {
 	fake.downloadProjectFileMutex.Lock()
 	defer fake.downloadProjectFileMutex.Unlock()
 	fake.DownloadProjectFileStub = stub
 }
 
-func (fake *FakeGitLab) DownloadProjectFileArgsForCall(i int) (string, string) {
+func (fake *FakeGitLab) DownloadProjectFileArgsForCall(i int) (context.Context, string, string, int64) {
 	fake.downloadProjectFileMutex.RLock()
 	defer fake.downloadProjectFileMutex.RUnlock()
 	argsForCall := fake.downloadProjectFileArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
-func (fake *FakeGitLab) DownloadProjectFileReturns(result1 error) {
+func (fake *FakeGitLab) DownloadProjectFileReturns(result1 int64, result2 error) {
 	fake.downloadProjectFileMutex.Lock()
 	defer fake.downloadProjectFileMutex.Unlock()
 	fake.DownloadProjectFileStub = nil
 	fake.downloadProjectFileReturns = struct {
-		result1 error
-	}{result1}
+		result1 int64
+		result2 error
+	}{result1, result2}
 }
 
-func (fake *FakeGitLab) DownloadProjectFileReturnsOnCall(i int, result1 error) {
+func (fake *FakeGitLab) DownloadProjectFileReturnsOnCall(i int, result1 int64, result2 error) {
 	fake.downloadProjectFileMutex.Lock()
 	defer fake.downloadProjectFileMutex.Unlock()
 	fake.DownloadProjectFileStub = nil
 	if fake.downloadProjectFileReturnsOnCall == nil {
 		fake.downloadProjectFileReturnsOnCall = make(map[int]struct {
+			result1 int64
+			result2 error
+		})
+	}
+	fake.downloadProjectFileReturnsOnCall[i] = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGitLab) DownloadProjectFileIfModified(arg1 context.Context, arg2 string, arg3 string, arg4 int64, arg5 resource.FileValidator) (resource.FileValidator, int64, error) {
+	fake.downloadProjectFileIfModifiedMutex.Lock()
+	ret, specificReturn := fake.downloadProjectFileIfModifiedReturnsOnCall[len(fake.downloadProjectFileIfModifiedArgsForCall)]
+	fake.downloadProjectFileIfModifiedArgsForCall = append(fake.downloadProjectFileIfModifiedArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 int64
+		arg5 resource.FileValidator
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.DownloadProjectFileIfModifiedStub
+	fakeReturns := fake.downloadProjectFileIfModifiedReturns
+	fake.recordInvocation("DownloadProjectFileIfModified", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.downloadProjectFileIfModifiedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeGitLab) DownloadProjectFileIfModifiedCallCount() int {
+	fake.downloadProjectFileIfModifiedMutex.RLock()
+	defer fake.downloadProjectFileIfModifiedMutex.RUnlock()
+	return len(fake.downloadProjectFileIfModifiedArgsForCall)
+}
+
+func (fake *FakeGitLab) DownloadProjectFileIfModifiedCalls(stub func(context.Context, string, string, int64, resource.FileValidator) (resource.FileValidator, int64, error)) {
+	fake.downloadProjectFileIfModifiedMutex.Lock()
+	defer fake.downloadProjectFileIfModifiedMutex.Unlock()
+	fake.DownloadProjectFileIfModifiedStub = stub
+}
+
+func (fake *FakeGitLab) DownloadProjectFileIfModifiedArgsForCall(i int) (context.Context, string, string, int64, resource.FileValidator) {
+	fake.downloadProjectFileIfModifiedMutex.RLock()
+	defer fake.downloadProjectFileIfModifiedMutex.RUnlock()
+	argsForCall := fake.downloadProjectFileIfModifiedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeGitLab) DownloadProjectFileIfModifiedReturns(result1 resource.FileValidator, result2 int64, result3 error) {
+	fake.downloadProjectFileIfModifiedMutex.Lock()
+	defer fake.downloadProjectFileIfModifiedMutex.Unlock()
+	fake.DownloadProjectFileIfModifiedStub = nil
+	fake.downloadProjectFileIfModifiedReturns = struct {
+		result1 resource.FileValidator
+		result2 int64
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeGitLab) DownloadProjectFileIfModifiedReturnsOnCall(i int, result1 resource.FileValidator, result2 int64, result3 error) {
+	fake.downloadProjectFileIfModifiedMutex.Lock()
+	defer fake.downloadProjectFileIfModifiedMutex.Unlock()
+	fake.DownloadProjectFileIfModifiedStub = nil
+	if fake.downloadProjectFileIfModifiedReturnsOnCall == nil {
+		fake.downloadProjectFileIfModifiedReturnsOnCall = make(map[int]struct {
+			result1 resource.FileValidator
+			result2 int64
+			result3 error
+		})
+	}
+	fake.downloadProjectFileIfModifiedReturnsOnCall[i] = struct {
+		result1 resource.FileValidator
+		result2 int64
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeGitLab) DownloadRepositoryFile(arg1 context.Context, arg2 string, arg3 string, arg4 string) error {
+	fake.downloadRepositoryFileMutex.Lock()
+	ret, specificReturn := fake.downloadRepositoryFileReturnsOnCall[len(fake.downloadRepositoryFileArgsForCall)]
+	fake.downloadRepositoryFileArgsForCall = append(fake.downloadRepositoryFileArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.DownloadRepositoryFileStub
+	fakeReturns := fake.downloadRepositoryFileReturns
+	fake.recordInvocation("DownloadRepositoryFile", []interface{}{arg1, arg2, arg3, arg4})
+	fake.downloadRepositoryFileMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeGitLab) DownloadRepositoryFileCallCount() int {
+	fake.downloadRepositoryFileMutex.RLock()
+	defer fake.downloadRepositoryFileMutex.RUnlock()
+	return len(fake.downloadRepositoryFileArgsForCall)
+}
+
+func (fake *FakeGitLab) DownloadRepositoryFileCalls(stub func(context.Context, string, string, string) error) {
+	fake.downloadRepositoryFileMutex.Lock()
+	defer fake.downloadRepositoryFileMutex.Unlock()
+	fake.DownloadRepositoryFileStub = stub
+}
+
+func (fake *FakeGitLab) DownloadRepositoryFileArgsForCall(i int) (context.Context, string, string, string) {
+	fake.downloadRepositoryFileMutex.RLock()
+	defer fake.downloadRepositoryFileMutex.RUnlock()
+	argsForCall := fake.downloadRepositoryFileArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeGitLab) DownloadRepositoryFileReturns(result1 error) {
+	fake.downloadRepositoryFileMutex.Lock()
+	defer fake.downloadRepositoryFileMutex.Unlock()
+	fake.DownloadRepositoryFileStub = nil
+	fake.downloadRepositoryFileReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGitLab) DownloadRepositoryFileReturnsOnCall(i int, result1 error) {
+	fake.downloadRepositoryFileMutex.Lock()
+	defer fake.downloadRepositoryFileMutex.Unlock()
+	fake.DownloadRepositoryFileStub = nil
+	if fake.downloadRepositoryFileReturnsOnCall == nil {
+		fake.downloadRepositoryFileReturnsOnCall = make(map[int]struct {
 			result1 error
 		})
 	}
-	fake.downloadProjectFileReturnsOnCall[i] = struct {
-		result1 error
-	}{result1}
+	fake.downloadRepositoryFileReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGitLab) GetAccessToken(arg1 context.Context) (*gitlab.PersonalAccessToken, error) {
+	fake.getAccessTokenMutex.Lock()
+	ret, specificReturn := fake.getAccessTokenReturnsOnCall[len(fake.getAccessTokenArgsForCall)]
+	fake.getAccessTokenArgsForCall = append(fake.getAccessTokenArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.GetAccessTokenStub
+	fakeReturns := fake.getAccessTokenReturns
+	fake.recordInvocation("GetAccessToken", []interface{}{arg1})
+	fake.getAccessTokenMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGitLab) GetAccessTokenCallCount() int {
+	fake.getAccessTokenMutex.RLock()
+	defer fake.getAccessTokenMutex.RUnlock()
+	return len(fake.getAccessTokenArgsForCall)
+}
+
+func (fake *FakeGitLab) GetAccessTokenCalls(stub func(context.Context) (*gitlab.PersonalAccessToken, error)) {
+	fake.getAccessTokenMutex.Lock()
+	defer fake.getAccessTokenMutex.Unlock()
+	fake.GetAccessTokenStub = stub
+}
+
+func (fake *FakeGitLab) GetAccessTokenArgsForCall(i int) context.Context {
+	fake.getAccessTokenMutex.RLock()
+	defer fake.getAccessTokenMutex.RUnlock()
+	argsForCall := fake.getAccessTokenArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeGitLab) GetAccessTokenReturns(result1 *gitlab.PersonalAccessToken, result2 error) {
+	fake.getAccessTokenMutex.Lock()
+	defer fake.getAccessTokenMutex.Unlock()
+	fake.GetAccessTokenStub = nil
+	fake.getAccessTokenReturns = struct {
+		result1 *gitlab.PersonalAccessToken
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGitLab) GetAccessTokenReturnsOnCall(i int, result1 *gitlab.PersonalAccessToken, result2 error) {
+	fake.getAccessTokenMutex.Lock()
+	defer fake.getAccessTokenMutex.Unlock()
+	fake.GetAccessTokenStub = nil
+	if fake.getAccessTokenReturnsOnCall == nil {
+		fake.getAccessTokenReturnsOnCall = make(map[int]struct {
+			result1 *gitlab.PersonalAccessToken
+			result2 error
+		})
+	}
+	fake.getAccessTokenReturnsOnCall[i] = struct {
+		result1 *gitlab.PersonalAccessToken
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGitLab) GetProject(arg1 context.Context) (*gitlab.Project, error) {
+	fake.getProjectMutex.Lock()
+	ret, specificReturn := fake.getProjectReturnsOnCall[len(fake.getProjectArgsForCall)]
+	fake.getProjectArgsForCall = append(fake.getProjectArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.GetProjectStub
+	fakeReturns := fake.getProjectReturns
+	fake.recordInvocation("GetProject", []interface{}{arg1})
+	fake.getProjectMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGitLab) GetProjectCallCount() int {
+	fake.getProjectMutex.RLock()
+	defer fake.getProjectMutex.RUnlock()
+	return len(fake.getProjectArgsForCall)
+}
+
+func (fake *FakeGitLab) GetProjectCalls(stub func(context.Context) (*gitlab.Project, error)) {
+	fake.getProjectMutex.Lock()
+	defer fake.getProjectMutex.Unlock()
+	fake.GetProjectStub = stub
+}
+
+func (fake *FakeGitLab) GetProjectArgsForCall(i int) context.Context {
+	fake.getProjectMutex.RLock()
+	defer fake.getProjectMutex.RUnlock()
+	argsForCall := fake.getProjectArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeGitLab) GetProjectReturns(result1 *gitlab.Project, result2 error) {
+	fake.getProjectMutex.Lock()
+	defer fake.getProjectMutex.Unlock()
+	fake.GetProjectStub = nil
+	fake.getProjectReturns = struct {
+		result1 *gitlab.Project
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGitLab) GetProjectReturnsOnCall(i int, result1 *gitlab.Project, result2 error) {
+	fake.getProjectMutex.Lock()
+	defer fake.getProjectMutex.Unlock()
+	fake.GetProjectStub = nil
+	if fake.getProjectReturnsOnCall == nil {
+		fake.getProjectReturnsOnCall = make(map[int]struct {
+			result1 *gitlab.Project
+			result2 error
+		})
+	}
+	fake.getProjectReturnsOnCall[i] = struct {
+		result1 *gitlab.Project
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGitLab) GetProjectFileSize(arg1 context.Context, arg2 string) (int64, error) {
+	fake.getProjectFileSizeMutex.Lock()
+	ret, specificReturn := fake.getProjectFileSizeReturnsOnCall[len(fake.getProjectFileSizeArgsForCall)]
+	fake.getProjectFileSizeArgsForCall = append(fake.getProjectFileSizeArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GetProjectFileSizeStub
+	fakeReturns := fake.getProjectFileSizeReturns
+	fake.recordInvocation("GetProjectFileSize", []interface{}{arg1, arg2})
+	fake.getProjectFileSizeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGitLab) GetProjectFileSizeCallCount() int {
+	fake.getProjectFileSizeMutex.RLock()
+	defer fake.getProjectFileSizeMutex.RUnlock()
+	return len(fake.getProjectFileSizeArgsForCall)
+}
+
+func (fake *FakeGitLab) GetProjectFileSizeCalls(stub func(context.Context, string) (int64, error)) {
+	fake.getProjectFileSizeMutex.Lock()
+	defer fake.getProjectFileSizeMutex.Unlock()
+	fake.GetProjectFileSizeStub = stub
+}
+
+func (fake *FakeGitLab) GetProjectFileSizeArgsForCall(i int) (context.Context, string) {
+	fake.getProjectFileSizeMutex.RLock()
+	defer fake.getProjectFileSizeMutex.RUnlock()
+	argsForCall := fake.getProjectFileSizeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGitLab) GetProjectFileSizeReturns(result1 int64, result2 error) {
+	fake.getProjectFileSizeMutex.Lock()
+	defer fake.getProjectFileSizeMutex.Unlock()
+	fake.GetProjectFileSizeStub = nil
+	fake.getProjectFileSizeReturns = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGitLab) GetProjectFileSizeReturnsOnCall(i int, result1 int64, result2 error) {
+	fake.getProjectFileSizeMutex.Lock()
+	defer fake.getProjectFileSizeMutex.Unlock()
+	fake.GetProjectFileSizeStub = nil
+	if fake.getProjectFileSizeReturnsOnCall == nil {
+		fake.getProjectFileSizeReturnsOnCall = make(map[int]struct {
+			result1 int64
+			result2 error
+		})
+	}
+	fake.getProjectFileSizeReturnsOnCall[i] = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGitLab) GetRegistryRepositoryTag(arg1 context.Context, arg2 int64, arg3 string) (*gitlab.RegistryRepositoryTag, error) {
+	fake.getRegistryRepositoryTagMutex.Lock()
+	ret, specificReturn := fake.getRegistryRepositoryTagReturnsOnCall[len(fake.getRegistryRepositoryTagArgsForCall)]
+	fake.getRegistryRepositoryTagArgsForCall = append(fake.getRegistryRepositoryTagArgsForCall, struct {
+		arg1 context.Context
+		arg2 int64
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.GetRegistryRepositoryTagStub
+	fakeReturns := fake.getRegistryRepositoryTagReturns
+	fake.recordInvocation("GetRegistryRepositoryTag", []interface{}{arg1, arg2, arg3})
+	fake.getRegistryRepositoryTagMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGitLab) GetRegistryRepositoryTagCallCount() int {
+	fake.getRegistryRepositoryTagMutex.RLock()
+	defer fake.getRegistryRepositoryTagMutex.RUnlock()
+	return len(fake.getRegistryRepositoryTagArgsForCall)
+}
+
+func (fake *FakeGitLab) GetRegistryRepositoryTagCalls(stub func(context.Context, int64, string) (*gitlab.RegistryRepositoryTag, error)) {
+	fake.getRegistryRepositoryTagMutex.Lock()
+	defer fake.getRegistryRepositoryTagMutex.Unlock()
+	fake.GetRegistryRepositoryTagStub = stub
+}
+
+func (fake *FakeGitLab) GetRegistryRepositoryTagArgsForCall(i int) (context.Context, int64, string) {
+	fake.getRegistryRepositoryTagMutex.RLock()
+	defer fake.getRegistryRepositoryTagMutex.RUnlock()
+	argsForCall := fake.getRegistryRepositoryTagArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeGitLab) GetRegistryRepositoryTagReturns(result1 *gitlab.RegistryRepositoryTag, result2 error) {
+	fake.getRegistryRepositoryTagMutex.Lock()
+	defer fake.getRegistryRepositoryTagMutex.Unlock()
+	fake.GetRegistryRepositoryTagStub = nil
+	fake.getRegistryRepositoryTagReturns = struct {
+		result1 *gitlab.RegistryRepositoryTag
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGitLab) GetRegistryRepositoryTagReturnsOnCall(i int, result1 *gitlab.RegistryRepositoryTag, result2 error) {
+	fake.getRegistryRepositoryTagMutex.Lock()
+	defer fake.getRegistryRepositoryTagMutex.Unlock()
+	fake.GetRegistryRepositoryTagStub = nil
+	if fake.getRegistryRepositoryTagReturnsOnCall == nil {
+		fake.getRegistryRepositoryTagReturnsOnCall = make(map[int]struct {
+			result1 *gitlab.RegistryRepositoryTag
+			result2 error
+		})
+	}
+	fake.getRegistryRepositoryTagReturnsOnCall[i] = struct {
+		result1 *gitlab.RegistryRepositoryTag
+		result2 error
+	}{result1, result2}
 }
 
-func (fake *FakeGitLab) GetRelease(arg1 string) (*gitlab.Release, error) {
+func (fake *FakeGitLab) GetRelease(arg1 context.Context, arg2 string) (*gitlab.Release, error) {
 	fake.getReleaseMutex.Lock()
 	ret, specificReturn := fake.getReleaseReturnsOnCall[len(fake.getReleaseArgsForCall)]
 	fake.getReleaseArgsForCall = append(fake.getReleaseArgsForCall, struct {
-		arg1 string
-	}{arg1})
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
 	stub := fake.GetReleaseStub
 	fakeReturns := fake.getReleaseReturns
-	fake.recordInvocation("GetRelease", []interface{}{arg1})
+	fake.recordInvocation("GetRelease", []interface{}{arg1, arg2})
 	fake.getReleaseMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -531,17 +1433,17 @@ func (fake *FakeGitLab) GetReleaseCallCount() int {
 	return len(fake.getReleaseArgsForCall)
 }
 
-func (fake *FakeGitLab) GetReleaseCalls(stub func(string) (*gitlab.Release, error)) {
+func (fake *FakeGitLab) GetReleaseCalls(stub func(context.Context, string) (*gitlab.Release, error)) {
 	fake.getReleaseMutex.Lock()
 	defer fake.getReleaseMutex.Unlock()
 	fake.GetReleaseStub = stub
 }
 
-func (fake *FakeGitLab) GetReleaseArgsForCall(i int) string {
+func (fake *FakeGitLab) GetReleaseArgsForCall(i int) (context.Context, string) {
 	fake.getReleaseMutex.RLock()
 	defer fake.getReleaseMutex.RUnlock()
 	argsForCall := fake.getReleaseArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeGitLab) GetReleaseReturns(result1 *gitlab.Release, result2 error) {
@@ -570,18 +1472,19 @@ func (fake *FakeGitLab) GetReleaseReturnsOnCall(i int, result1 *gitlab.Release,
 	}{result1, result2}
 }
 
-func (fake *FakeGitLab) GetReleaseLinks(arg1 string) ([]*gitlab.ReleaseLink, error) {
+func (fake *FakeGitLab) GetReleaseLinks(arg1 context.Context, arg2 string) ([]*gitlab.ReleaseLink, error) {
 	fake.getReleaseLinksMutex.Lock()
 	ret, specificReturn := fake.getReleaseLinksReturnsOnCall[len(fake.getReleaseLinksArgsForCall)]
 	fake.getReleaseLinksArgsForCall = append(fake.getReleaseLinksArgsForCall, struct {
-		arg1 string
-	}{arg1})
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
 	stub := fake.GetReleaseLinksStub
 	fakeReturns := fake.getReleaseLinksReturns
-	fake.recordInvocation("GetReleaseLinks", []interface{}{arg1})
+	fake.recordInvocation("GetReleaseLinks", []interface{}{arg1, arg2})
 	fake.getReleaseLinksMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -595,17 +1498,17 @@ func (fake *FakeGitLab) GetReleaseLinksCallCount() int {
 	return len(fake.getReleaseLinksArgsForCall)
 }
 
-func (fake *FakeGitLab) GetReleaseLinksCalls(stub func(string) ([]*gitlab.ReleaseLink, error)) {
+func (fake *FakeGitLab) GetReleaseLinksCalls(stub func(context.Context, string) ([]*gitlab.ReleaseLink, error)) {
 	fake.getReleaseLinksMutex.Lock()
 	defer fake.getReleaseLinksMutex.Unlock()
 	fake.GetReleaseLinksStub = stub
 }
 
-func (fake *FakeGitLab) GetReleaseLinksArgsForCall(i int) string {
+func (fake *FakeGitLab) GetReleaseLinksArgsForCall(i int) (context.Context, string) {
 	fake.getReleaseLinksMutex.RLock()
 	defer fake.getReleaseLinksMutex.RUnlock()
 	argsForCall := fake.getReleaseLinksArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeGitLab) GetReleaseLinksReturns(result1 []*gitlab.ReleaseLink, result2 error) {
@@ -634,18 +1537,19 @@ func (fake *FakeGitLab) GetReleaseLinksReturnsOnCall(i int, result1 []*gitlab.Re
 	}{result1, result2}
 }
 
-func (fake *FakeGitLab) GetTag(arg1 string) (*gitlab.Tag, error) {
+func (fake *FakeGitLab) GetTag(arg1 context.Context, arg2 string) (*gitlab.Tag, error) {
 	fake.getTagMutex.Lock()
 	ret, specificReturn := fake.getTagReturnsOnCall[len(fake.getTagArgsForCall)]
 	fake.getTagArgsForCall = append(fake.getTagArgsForCall, struct {
-		arg1 string
-	}{arg1})
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
 	stub := fake.GetTagStub
 	fakeReturns := fake.getTagReturns
-	fake.recordInvocation("GetTag", []interface{}{arg1})
+	fake.recordInvocation("GetTag", []interface{}{arg1, arg2})
 	fake.getTagMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -659,17 +1563,17 @@ func (fake *FakeGitLab) GetTagCallCount() int {
 	return len(fake.getTagArgsForCall)
 }
 
-func (fake *FakeGitLab) GetTagCalls(stub func(string) (*gitlab.Tag, error)) {
+func (fake *FakeGitLab) GetTagCalls(stub func(context.Context, string) (*gitlab.Tag, error)) {
 	fake.getTagMutex.Lock()
 	defer fake.getTagMutex.Unlock()
 	fake.GetTagStub = stub
 }
 
-func (fake *FakeGitLab) GetTagArgsForCall(i int) string {
+func (fake *FakeGitLab) GetTagArgsForCall(i int) (context.Context, string) {
 	fake.getTagMutex.RLock()
 	defer fake.getTagMutex.RUnlock()
 	argsForCall := fake.getTagArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeGitLab) GetTagReturns(result1 *gitlab.Tag, result2 error) {
@@ -698,17 +1602,147 @@ func (fake *FakeGitLab) GetTagReturnsOnCall(i int, result1 *gitlab.Tag, result2
 	}{result1, result2}
 }
 
-func (fake *FakeGitLab) ListReleases() ([]*gitlab.Release, error) {
+func (fake *FakeGitLab) ListMergeRequestsByCommit(arg1 context.Context, arg2 string) ([]*gitlab.BasicMergeRequest, error) {
+	fake.listMergeRequestsByCommitMutex.Lock()
+	ret, specificReturn := fake.listMergeRequestsByCommitReturnsOnCall[len(fake.listMergeRequestsByCommitArgsForCall)]
+	fake.listMergeRequestsByCommitArgsForCall = append(fake.listMergeRequestsByCommitArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ListMergeRequestsByCommitStub
+	fakeReturns := fake.listMergeRequestsByCommitReturns
+	fake.recordInvocation("ListMergeRequestsByCommit", []interface{}{arg1, arg2})
+	fake.listMergeRequestsByCommitMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGitLab) ListMergeRequestsByCommitCallCount() int {
+	fake.listMergeRequestsByCommitMutex.RLock()
+	defer fake.listMergeRequestsByCommitMutex.RUnlock()
+	return len(fake.listMergeRequestsByCommitArgsForCall)
+}
+
+func (fake *FakeGitLab) ListMergeRequestsByCommitCalls(stub func(context.Context, string) ([]*gitlab.BasicMergeRequest, error)) {
+	fake.listMergeRequestsByCommitMutex.Lock()
+	defer fake.listMergeRequestsByCommitMutex.Unlock()
+	fake.ListMergeRequestsByCommitStub = stub
+}
+
+func (fake *FakeGitLab) ListMergeRequestsByCommitArgsForCall(i int) (context.Context, string) {
+	fake.listMergeRequestsByCommitMutex.RLock()
+	defer fake.listMergeRequestsByCommitMutex.RUnlock()
+	argsForCall := fake.listMergeRequestsByCommitArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGitLab) ListMergeRequestsByCommitReturns(result1 []*gitlab.BasicMergeRequest, result2 error) {
+	fake.listMergeRequestsByCommitMutex.Lock()
+	defer fake.listMergeRequestsByCommitMutex.Unlock()
+	fake.ListMergeRequestsByCommitStub = nil
+	fake.listMergeRequestsByCommitReturns = struct {
+		result1 []*gitlab.BasicMergeRequest
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGitLab) ListMergeRequestsByCommitReturnsOnCall(i int, result1 []*gitlab.BasicMergeRequest, result2 error) {
+	fake.listMergeRequestsByCommitMutex.Lock()
+	defer fake.listMergeRequestsByCommitMutex.Unlock()
+	fake.ListMergeRequestsByCommitStub = nil
+	if fake.listMergeRequestsByCommitReturnsOnCall == nil {
+		fake.listMergeRequestsByCommitReturnsOnCall = make(map[int]struct {
+			result1 []*gitlab.BasicMergeRequest
+			result2 error
+		})
+	}
+	fake.listMergeRequestsByCommitReturnsOnCall[i] = struct {
+		result1 []*gitlab.BasicMergeRequest
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGitLab) ListRegistryRepositories(arg1 context.Context) ([]*gitlab.RegistryRepository, error) {
+	fake.listRegistryRepositoriesMutex.Lock()
+	ret, specificReturn := fake.listRegistryRepositoriesReturnsOnCall[len(fake.listRegistryRepositoriesArgsForCall)]
+	fake.listRegistryRepositoriesArgsForCall = append(fake.listRegistryRepositoriesArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.ListRegistryRepositoriesStub
+	fakeReturns := fake.listRegistryRepositoriesReturns
+	fake.recordInvocation("ListRegistryRepositories", []interface{}{arg1})
+	fake.listRegistryRepositoriesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGitLab) ListRegistryRepositoriesCallCount() int {
+	fake.listRegistryRepositoriesMutex.RLock()
+	defer fake.listRegistryRepositoriesMutex.RUnlock()
+	return len(fake.listRegistryRepositoriesArgsForCall)
+}
+
+func (fake *FakeGitLab) ListRegistryRepositoriesCalls(stub func(context.Context) ([]*gitlab.RegistryRepository, error)) {
+	fake.listRegistryRepositoriesMutex.Lock()
+	defer fake.listRegistryRepositoriesMutex.Unlock()
+	fake.ListRegistryRepositoriesStub = stub
+}
+
+func (fake *FakeGitLab) ListRegistryRepositoriesArgsForCall(i int) context.Context {
+	fake.listRegistryRepositoriesMutex.RLock()
+	defer fake.listRegistryRepositoriesMutex.RUnlock()
+	argsForCall := fake.listRegistryRepositoriesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeGitLab) ListRegistryRepositoriesReturns(result1 []*gitlab.RegistryRepository, result2 error) {
+	fake.listRegistryRepositoriesMutex.Lock()
+	defer fake.listRegistryRepositoriesMutex.Unlock()
+	fake.ListRegistryRepositoriesStub = nil
+	fake.listRegistryRepositoriesReturns = struct {
+		result1 []*gitlab.RegistryRepository
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGitLab) ListRegistryRepositoriesReturnsOnCall(i int, result1 []*gitlab.RegistryRepository, result2 error) {
+	fake.listRegistryRepositoriesMutex.Lock()
+	defer fake.listRegistryRepositoriesMutex.Unlock()
+	fake.ListRegistryRepositoriesStub = nil
+	if fake.listRegistryRepositoriesReturnsOnCall == nil {
+		fake.listRegistryRepositoriesReturnsOnCall = make(map[int]struct {
+			result1 []*gitlab.RegistryRepository
+			result2 error
+		})
+	}
+	fake.listRegistryRepositoriesReturnsOnCall[i] = struct {
+		result1 []*gitlab.RegistryRepository
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGitLab) ListReleases(arg1 context.Context) ([]*gitlab.Release, error) {
 	fake.listReleasesMutex.Lock()
 	ret, specificReturn := fake.listReleasesReturnsOnCall[len(fake.listReleasesArgsForCall)]
 	fake.listReleasesArgsForCall = append(fake.listReleasesArgsForCall, struct {
-	}{})
+		arg1 context.Context
+	}{arg1})
 	stub := fake.ListReleasesStub
 	fakeReturns := fake.listReleasesReturns
-	fake.recordInvocation("ListReleases", []interface{}{})
+	fake.recordInvocation("ListReleases", []interface{}{arg1})
 	fake.listReleasesMutex.Unlock()
 	if stub != nil {
-		return stub()
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -722,12 +1756,19 @@ func (fake *FakeGitLab) ListReleasesCallCount() int {
 	return len(fake.listReleasesArgsForCall)
 }
 
-func (fake *FakeGitLab) ListReleasesCalls(stub func() ([]*gitlab.Release, error)) {
+func (fake *FakeGitLab) ListReleasesCalls(stub func(context.Context) ([]*gitlab.Release, error)) {
 	fake.listReleasesMutex.Lock()
 	defer fake.listReleasesMutex.Unlock()
 	fake.ListReleasesStub = stub
 }
 
+func (fake *FakeGitLab) ListReleasesArgsForCall(i int) context.Context {
+	fake.listReleasesMutex.RLock()
+	defer fake.listReleasesMutex.RUnlock()
+	argsForCall := fake.listReleasesArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeGitLab) ListReleasesReturns(result1 []*gitlab.Release, result2 error) {
 	fake.listReleasesMutex.Lock()
 	defer fake.listReleasesMutex.Unlock()
@@ -754,17 +1795,83 @@ func (fake *FakeGitLab) ListReleasesReturnsOnCall(i int, result1 []*gitlab.Relea
 	}{result1, result2}
 }
 
-func (fake *FakeGitLab) ListTags() ([]*gitlab.Tag, error) {
+func (fake *FakeGitLab) ListRepositoryTree(arg1 context.Context, arg2 string) ([]*gitlab.TreeNode, error) {
+	fake.listRepositoryTreeMutex.Lock()
+	ret, specificReturn := fake.listRepositoryTreeReturnsOnCall[len(fake.listRepositoryTreeArgsForCall)]
+	fake.listRepositoryTreeArgsForCall = append(fake.listRepositoryTreeArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ListRepositoryTreeStub
+	fakeReturns := fake.listRepositoryTreeReturns
+	fake.recordInvocation("ListRepositoryTree", []interface{}{arg1, arg2})
+	fake.listRepositoryTreeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGitLab) ListRepositoryTreeCallCount() int {
+	fake.listRepositoryTreeMutex.RLock()
+	defer fake.listRepositoryTreeMutex.RUnlock()
+	return len(fake.listRepositoryTreeArgsForCall)
+}
+
+func (fake *FakeGitLab) ListRepositoryTreeCalls(stub func(context.Context, string) ([]*gitlab.TreeNode, error)) {
+	fake.listRepositoryTreeMutex.Lock()
+	defer fake.listRepositoryTreeMutex.Unlock()
+	fake.ListRepositoryTreeStub = stub
+}
+
+func (fake *FakeGitLab) ListRepositoryTreeArgsForCall(i int) (context.Context, string) {
+	fake.listRepositoryTreeMutex.RLock()
+	defer fake.listRepositoryTreeMutex.RUnlock()
+	argsForCall := fake.listRepositoryTreeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGitLab) ListRepositoryTreeReturns(result1 []*gitlab.TreeNode, result2 error) {
+	fake.listRepositoryTreeMutex.Lock()
+	defer fake.listRepositoryTreeMutex.Unlock()
+	fake.ListRepositoryTreeStub = nil
+	fake.listRepositoryTreeReturns = struct {
+		result1 []*gitlab.TreeNode
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGitLab) ListRepositoryTreeReturnsOnCall(i int, result1 []*gitlab.TreeNode, result2 error) {
+	fake.listRepositoryTreeMutex.Lock()
+	defer fake.listRepositoryTreeMutex.Unlock()
+	fake.ListRepositoryTreeStub = nil
+	if fake.listRepositoryTreeReturnsOnCall == nil {
+		fake.listRepositoryTreeReturnsOnCall = make(map[int]struct {
+			result1 []*gitlab.TreeNode
+			result2 error
+		})
+	}
+	fake.listRepositoryTreeReturnsOnCall[i] = struct {
+		result1 []*gitlab.TreeNode
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGitLab) ListTags(arg1 context.Context) ([]*gitlab.Tag, error) {
 	fake.listTagsMutex.Lock()
 	ret, specificReturn := fake.listTagsReturnsOnCall[len(fake.listTagsArgsForCall)]
 	fake.listTagsArgsForCall = append(fake.listTagsArgsForCall, struct {
-	}{})
+		arg1 context.Context
+	}{arg1})
 	stub := fake.ListTagsStub
 	fakeReturns := fake.listTagsReturns
-	fake.recordInvocation("ListTags", []interface{}{})
+	fake.recordInvocation("ListTags", []interface{}{arg1})
 	fake.listTagsMutex.Unlock()
 	if stub != nil {
-		return stub()
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -778,12 +1885,19 @@ func (fake *FakeGitLab) ListTagsCallCount() int {
 	return len(fake.listTagsArgsForCall)
 }
 
-func (fake *FakeGitLab) ListTagsCalls(stub func() ([]*gitlab.Tag, error)) {
+func (fake *FakeGitLab) ListTagsCalls(stub func(context.Context) ([]*gitlab.Tag, error)) {
 	fake.listTagsMutex.Lock()
 	defer fake.listTagsMutex.Unlock()
 	fake.ListTagsStub = stub
 }
 
+func (fake *FakeGitLab) ListTagsArgsForCall(i int) context.Context {
+	fake.listTagsMutex.RLock()
+	defer fake.listTagsMutex.RUnlock()
+	argsForCall := fake.listTagsArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeGitLab) ListTagsReturns(result1 []*gitlab.Tag, result2 error) {
 	fake.listTagsMutex.Lock()
 	defer fake.listTagsMutex.Unlock()
@@ -810,18 +1924,19 @@ func (fake *FakeGitLab) ListTagsReturnsOnCall(i int, result1 []*gitlab.Tag, resu
 	}{result1, result2}
 }
 
-func (fake *FakeGitLab) ListTagsUntil(arg1 string) ([]*gitlab.Tag, error) {
+func (fake *FakeGitLab) ListTagsUntil(arg1 context.Context, arg2 string) ([]*gitlab.Tag, error) {
 	fake.listTagsUntilMutex.Lock()
 	ret, specificReturn := fake.listTagsUntilReturnsOnCall[len(fake.listTagsUntilArgsForCall)]
 	fake.listTagsUntilArgsForCall = append(fake.listTagsUntilArgsForCall, struct {
-		arg1 string
-	}{arg1})
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
 	stub := fake.ListTagsUntilStub
 	fakeReturns := fake.listTagsUntilReturns
-	fake.recordInvocation("ListTagsUntil", []interface{}{arg1})
+	fake.recordInvocation("ListTagsUntil", []interface{}{arg1, arg2})
 	fake.listTagsUntilMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -835,17 +1950,17 @@ func (fake *FakeGitLab) ListTagsUntilCallCount() int {
 	return len(fake.listTagsUntilArgsForCall)
 }
 
-func (fake *FakeGitLab) ListTagsUntilCalls(stub func(string) ([]*gitlab.Tag, error)) {
+func (fake *FakeGitLab) ListTagsUntilCalls(stub func(context.Context, string) ([]*gitlab.Tag, error)) {
 	fake.listTagsUntilMutex.Lock()
 	defer fake.listTagsUntilMutex.Unlock()
 	fake.ListTagsUntilStub = stub
 }
 
-func (fake *FakeGitLab) ListTagsUntilArgsForCall(i int) string {
+func (fake *FakeGitLab) ListTagsUntilArgsForCall(i int) (context.Context, string) {
 	fake.listTagsUntilMutex.RLock()
 	defer fake.listTagsUntilMutex.RUnlock()
 	argsForCall := fake.listTagsUntilArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeGitLab) ListTagsUntilReturns(result1 []*gitlab.Tag, result2 error) {
@@ -874,20 +1989,88 @@ func (fake *FakeGitLab) ListTagsUntilReturnsOnCall(i int, result1 []*gitlab.Tag,
 	}{result1, result2}
 }
 
-func (fake *FakeGitLab) UpdateRelease(arg1 string, arg2 string, arg3 *string) (*gitlab.Release, error) {
+func (fake *FakeGitLab) PublishPackageFile(arg1 context.Context, arg2 string, arg3 string, arg4 string) (string, error) {
+	fake.publishPackageFileMutex.Lock()
+	ret, specificReturn := fake.publishPackageFileReturnsOnCall[len(fake.publishPackageFileArgsForCall)]
+	fake.publishPackageFileArgsForCall = append(fake.publishPackageFileArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.PublishPackageFileStub
+	fakeReturns := fake.publishPackageFileReturns
+	fake.recordInvocation("PublishPackageFile", []interface{}{arg1, arg2, arg3, arg4})
+	fake.publishPackageFileMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGitLab) PublishPackageFileCallCount() int {
+	fake.publishPackageFileMutex.RLock()
+	defer fake.publishPackageFileMutex.RUnlock()
+	return len(fake.publishPackageFileArgsForCall)
+}
+
+func (fake *FakeGitLab) PublishPackageFileCalls(stub func(context.Context, string, string, string) (string, error)) {
+	fake.publishPackageFileMutex.Lock()
+	defer fake.publishPackageFileMutex.Unlock()
+	fake.PublishPackageFileStub = stub
+}
+
+func (fake *FakeGitLab) PublishPackageFileArgsForCall(i int) (context.Context, string, string, string) {
+	fake.publishPackageFileMutex.RLock()
+	defer fake.publishPackageFileMutex.RUnlock()
+	argsForCall := fake.publishPackageFileArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeGitLab) PublishPackageFileReturns(result1 string, result2 error) {
+	fake.publishPackageFileMutex.Lock()
+	defer fake.publishPackageFileMutex.Unlock()
+	fake.PublishPackageFileStub = nil
+	fake.publishPackageFileReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGitLab) PublishPackageFileReturnsOnCall(i int, result1 string, result2 error) {
+	fake.publishPackageFileMutex.Lock()
+	defer fake.publishPackageFileMutex.Unlock()
+	fake.PublishPackageFileStub = nil
+	if fake.publishPackageFileReturnsOnCall == nil {
+		fake.publishPackageFileReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.publishPackageFileReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGitLab) UpdateRelease(arg1 context.Context, arg2 string, arg3 string, arg4 *string) (*gitlab.Release, error) {
 	fake.updateReleaseMutex.Lock()
 	ret, specificReturn := fake.updateReleaseReturnsOnCall[len(fake.updateReleaseArgsForCall)]
 	fake.updateReleaseArgsForCall = append(fake.updateReleaseArgsForCall, struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-		arg3 *string
-	}{arg1, arg2, arg3})
+		arg3 string
+		arg4 *string
+	}{arg1, arg2, arg3, arg4})
 	stub := fake.UpdateReleaseStub
 	fakeReturns := fake.updateReleaseReturns
-	fake.recordInvocation("UpdateRelease", []interface{}{arg1, arg2, arg3})
+	fake.recordInvocation("UpdateRelease", []interface{}{arg1, arg2, arg3, arg4})
 	fake.updateReleaseMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2, arg3)
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -901,17 +2084,17 @@ func (fake *FakeGitLab) UpdateReleaseCallCount() int {
 	return len(fake.updateReleaseArgsForCall)
 }
 
-func (fake *FakeGitLab) UpdateReleaseCalls(stub func(string, string, *string) (*gitlab.Release, error)) {
+func (fake *FakeGitLab) UpdateReleaseCalls(stub func(context.Context, string, string, *string) (*gitlab.Release, error)) {
 	fake.updateReleaseMutex.Lock()
 	defer fake.updateReleaseMutex.Unlock()
 	fake.UpdateReleaseStub = stub
 }
 
-func (fake *FakeGitLab) UpdateReleaseArgsForCall(i int) (string, string, *string) {
+func (fake *FakeGitLab) UpdateReleaseArgsForCall(i int) (context.Context, string, string, *string) {
 	fake.updateReleaseMutex.RLock()
 	defer fake.updateReleaseMutex.RUnlock()
 	argsForCall := fake.updateReleaseArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
 func (fake *FakeGitLab) UpdateReleaseReturns(result1 *gitlab.Release, result2 error) {
@@ -940,18 +2123,149 @@ func (fake *FakeGitLab) UpdateReleaseReturnsOnCall(i int, result1 *gitlab.Releas
 	}{result1, result2}
 }
 
-func (fake *FakeGitLab) UploadProjectFile(arg1 string) (*gitlab.ProjectMarkdownUploadedFile, error) {
+func (fake *FakeGitLab) UpdateReleaseLink(arg1 context.Context, arg2 string, arg3 *gitlab.ReleaseLink, arg4 resource.ReleaseLinkSpec) (*gitlab.ReleaseLink, error) {
+	fake.updateReleaseLinkMutex.Lock()
+	ret, specificReturn := fake.updateReleaseLinkReturnsOnCall[len(fake.updateReleaseLinkArgsForCall)]
+	fake.updateReleaseLinkArgsForCall = append(fake.updateReleaseLinkArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 *gitlab.ReleaseLink
+		arg4 resource.ReleaseLinkSpec
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.UpdateReleaseLinkStub
+	fakeReturns := fake.updateReleaseLinkReturns
+	fake.recordInvocation("UpdateReleaseLink", []interface{}{arg1, arg2, arg3, arg4})
+	fake.updateReleaseLinkMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGitLab) UpdateReleaseLinkCallCount() int {
+	fake.updateReleaseLinkMutex.RLock()
+	defer fake.updateReleaseLinkMutex.RUnlock()
+	return len(fake.updateReleaseLinkArgsForCall)
+}
+
+func (fake *FakeGitLab) UpdateReleaseLinkCalls(stub func(context.Context, string, *gitlab.ReleaseLink, resource.ReleaseLinkSpec) (*gitlab.ReleaseLink, error)) {
+	fake.updateReleaseLinkMutex.Lock()
+	defer fake.updateReleaseLinkMutex.Unlock()
+	fake.UpdateReleaseLinkStub = stub
+}
+
+func (fake *FakeGitLab) UpdateReleaseLinkArgsForCall(i int) (context.Context, string, *gitlab.ReleaseLink, resource.ReleaseLinkSpec) {
+	fake.updateReleaseLinkMutex.RLock()
+	defer fake.updateReleaseLinkMutex.RUnlock()
+	argsForCall := fake.updateReleaseLinkArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeGitLab) UpdateReleaseLinkReturns(result1 *gitlab.ReleaseLink, result2 error) {
+	fake.updateReleaseLinkMutex.Lock()
+	defer fake.updateReleaseLinkMutex.Unlock()
+	fake.UpdateReleaseLinkStub = nil
+	fake.updateReleaseLinkReturns = struct {
+		result1 *gitlab.ReleaseLink
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGitLab) UpdateReleaseLinkReturnsOnCall(i int, result1 *gitlab.ReleaseLink, result2 error) {
+	fake.updateReleaseLinkMutex.Lock()
+	defer fake.updateReleaseLinkMutex.Unlock()
+	fake.UpdateReleaseLinkStub = nil
+	if fake.updateReleaseLinkReturnsOnCall == nil {
+		fake.updateReleaseLinkReturnsOnCall = make(map[int]struct {
+			result1 *gitlab.ReleaseLink
+			result2 error
+		})
+	}
+	fake.updateReleaseLinkReturnsOnCall[i] = struct {
+		result1 *gitlab.ReleaseLink
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGitLab) UploadObject(arg1 context.Context, arg2 string, arg3 string) error {
+	fake.uploadObjectMutex.Lock()
+	ret, specificReturn := fake.uploadObjectReturnsOnCall[len(fake.uploadObjectArgsForCall)]
+	fake.uploadObjectArgsForCall = append(fake.uploadObjectArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.UploadObjectStub
+	fakeReturns := fake.uploadObjectReturns
+	fake.recordInvocation("UploadObject", []interface{}{arg1, arg2, arg3})
+	fake.uploadObjectMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeGitLab) UploadObjectCallCount() int {
+	fake.uploadObjectMutex.RLock()
+	defer fake.uploadObjectMutex.RUnlock()
+	return len(fake.uploadObjectArgsForCall)
+}
+
+func (fake *FakeGitLab) UploadObjectCalls(stub func(context.Context, string, string) error) {
+	fake.uploadObjectMutex.Lock()
+	defer fake.uploadObjectMutex.Unlock()
+	fake.UploadObjectStub = stub
+}
+
+func (fake *FakeGitLab) UploadObjectArgsForCall(i int) (context.Context, string, string) {
+	fake.uploadObjectMutex.RLock()
+	defer fake.uploadObjectMutex.RUnlock()
+	argsForCall := fake.uploadObjectArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeGitLab) UploadObjectReturns(result1 error) {
+	fake.uploadObjectMutex.Lock()
+	defer fake.uploadObjectMutex.Unlock()
+	fake.UploadObjectStub = nil
+	fake.uploadObjectReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGitLab) UploadObjectReturnsOnCall(i int, result1 error) {
+	fake.uploadObjectMutex.Lock()
+	defer fake.uploadObjectMutex.Unlock()
+	fake.UploadObjectStub = nil
+	if fake.uploadObjectReturnsOnCall == nil {
+		fake.uploadObjectReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.uploadObjectReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGitLab) UploadProjectFile(arg1 context.Context, arg2 string) (*gitlab.ProjectMarkdownUploadedFile, error) {
 	fake.uploadProjectFileMutex.Lock()
 	ret, specificReturn := fake.uploadProjectFileReturnsOnCall[len(fake.uploadProjectFileArgsForCall)]
 	fake.uploadProjectFileArgsForCall = append(fake.uploadProjectFileArgsForCall, struct {
-		arg1 string
-	}{arg1})
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
 	stub := fake.UploadProjectFileStub
 	fakeReturns := fake.uploadProjectFileReturns
-	fake.recordInvocation("UploadProjectFile", []interface{}{arg1})
+	fake.recordInvocation("UploadProjectFile", []interface{}{arg1, arg2})
 	fake.uploadProjectFileMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -965,17 +2279,17 @@ func (fake *FakeGitLab) UploadProjectFileCallCount() int {
 	return len(fake.uploadProjectFileArgsForCall)
 }
 
-func (fake *FakeGitLab) UploadProjectFileCalls(stub func(string) (*gitlab.ProjectMarkdownUploadedFile, error)) {
+func (fake *FakeGitLab) UploadProjectFileCalls(stub func(context.Context, string) (*gitlab.ProjectMarkdownUploadedFile, error)) {
 	fake.uploadProjectFileMutex.Lock()
 	defer fake.uploadProjectFileMutex.Unlock()
 	fake.UploadProjectFileStub = stub
 }
 
-func (fake *FakeGitLab) UploadProjectFileArgsForCall(i int) string {
+func (fake *FakeGitLab) UploadProjectFileArgsForCall(i int) (context.Context, string) {
 	fake.uploadProjectFileMutex.RLock()
 	defer fake.uploadProjectFileMutex.RUnlock()
 	argsForCall := fake.uploadProjectFileArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeGitLab) UploadProjectFileReturns(result1 *gitlab.ProjectMarkdownUploadedFile, result2 error) {
@@ -1007,32 +2321,6 @@ func (fake *FakeGitLab) UploadProjectFileReturnsOnCall(i int, result1 *gitlab.Pr
 func (fake *FakeGitLab) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.createReleaseMutex.RLock()
-	defer fake.createReleaseMutex.RUnlock()
-	fake.createReleaseLinkMutex.RLock()
-	defer fake.createReleaseLinkMutex.RUnlock()
-	fake.createTagMutex.RLock()
-	defer fake.createTagMutex.RUnlock()
-	fake.deleteReleaseLinkMutex.RLock()
-	defer fake.deleteReleaseLinkMutex.RUnlock()
-	fake.downloadProjectFileMutex.RLock()
-	defer fake.downloadProjectFileMutex.RUnlock()
-	fake.getReleaseMutex.RLock()
-	defer fake.getReleaseMutex.RUnlock()
-	fake.getReleaseLinksMutex.RLock()
-	defer fake.getReleaseLinksMutex.RUnlock()
-	fake.getTagMutex.RLock()
-	defer fake.getTagMutex.RUnlock()
-	fake.listReleasesMutex.RLock()
-	defer fake.listReleasesMutex.RUnlock()
-	fake.listTagsMutex.RLock()
-	defer fake.listTagsMutex.RUnlock()
-	fake.listTagsUntilMutex.RLock()
-	defer fake.listTagsUntilMutex.RUnlock()
-	fake.updateReleaseMutex.RLock()
-	defer fake.updateReleaseMutex.RUnlock()
-	fake.uploadProjectFileMutex.RLock()
-	defer fake.uploadProjectFileMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -9,6 +9,9 @@ import (
 
 func Fatal(doing string, err error) {
 	Sayf(colorstring.Color("[red]error %s: %s\n"), doing, err)
+	if hint := errorHint(err); hint != "" {
+		Sayf(colorstring.Color("[yellow]hint: %s\n"), hint)
+	}
 	os.Exit(1)
 }
 
@@ -2,43 +2,76 @@ package resource
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	gitlab "gitlab.com/gitlab-org/api/client-go"
-	"golang.org/x/oauth2"
 )
 
 var (
-	ErrNotFound = errors.New("object not found")
+	ErrSizeLimitExceeded = errors.New("size limit exceeded")
+	ErrNotModified       = errors.New("not modified")
 )
 
 //go:generate counterfeiter . GitLab
 
 type GitLab interface {
-	ListTags() ([]*gitlab.Tag, error)
-	ListTagsUntil(tag_name string) ([]*gitlab.Tag, error)
-	ListReleases() ([]*gitlab.Release, error)
-	GetRelease(tag_name string) (*gitlab.Release, error)
-	GetTag(tag_name string) (*gitlab.Tag, error)
-	CreateTag(tag_name string, ref string) (*gitlab.Tag, error)
-	CreateRelease(name string, tag string, description *string) (*gitlab.Release, error)
-	UpdateRelease(name string, tag string, description *string) (*gitlab.Release, error)
-
-	UploadProjectFile(file string) (*gitlab.ProjectMarkdownUploadedFile, error)
-	DownloadProjectFile(url, file string) error
+	ListTags(ctx context.Context) ([]*gitlab.Tag, error)
+	ListTagsUntil(ctx context.Context, tag_name string) ([]*gitlab.Tag, error)
+	ListReleases(ctx context.Context) ([]*gitlab.Release, error)
+	GetRelease(ctx context.Context, tag_name string) (*gitlab.Release, error)
+	GetTag(ctx context.Context, tag_name string) (*gitlab.Tag, error)
+	CreateTag(ctx context.Context, tag_name string, ref string) (*gitlab.Tag, error)
+	CreateRelease(ctx context.Context, name string, tag string, description *string) (*gitlab.Release, error)
+	UpdateRelease(ctx context.Context, name string, tag string, description *string) (*gitlab.Release, error)
+	DeleteRelease(ctx context.Context, tag string) error
+	DeleteTag(ctx context.Context, tag string) error
+
+	UploadProjectFile(ctx context.Context, file string) (*gitlab.ProjectMarkdownUploadedFile, error)
+	UploadObject(ctx context.Context, url string, file string) error
+	PublishPackageFile(ctx context.Context, packageName string, packageVersion string, file string) (string, error)
+	GetProjectFileSize(ctx context.Context, url string) (int64, error)
+	DownloadProjectFile(ctx context.Context, url string, file string, maxSize int64) (int64, error)
+	DownloadProjectFileIfModified(ctx context.Context, url string, file string, maxSize int64, validator FileValidator) (FileValidator, int64, error)
+
+	GetReleaseLinks(ctx context.Context, tag string) ([]*gitlab.ReleaseLink, error)
+	CreateReleaseLink(ctx context.Context, tag string, spec ReleaseLinkSpec) (*gitlab.ReleaseLink, error)
+	UpdateReleaseLink(ctx context.Context, tag string, link *gitlab.ReleaseLink, spec ReleaseLinkSpec) (*gitlab.ReleaseLink, error)
+	DeleteReleaseLink(ctx context.Context, tag string, links *gitlab.ReleaseLink) error
+
+	CompareRefs(ctx context.Context, from string, to string) (*gitlab.Compare, error)
+	ListMergeRequestsByCommit(ctx context.Context, sha string) ([]*gitlab.BasicMergeRequest, error)
+
+	ListRepositoryTree(ctx context.Context, ref string) ([]*gitlab.TreeNode, error)
+	DownloadRepositoryFile(ctx context.Context, filePath string, ref string, destPath string) error
+	DownloadArchive(ctx context.Context, format string, sha string, subPath string, destPath string) error
+
+	ListRegistryRepositories(ctx context.Context) ([]*gitlab.RegistryRepository, error)
+	GetRegistryRepositoryTag(ctx context.Context, repositoryID int64, tag string) (*gitlab.RegistryRepositoryTag, error)
+
+	GetAccessToken(ctx context.Context) (*gitlab.PersonalAccessToken, error)
+	GetProject(ctx context.Context) (*gitlab.Project, error)
+}
 
-	GetReleaseLinks(tag string) ([]*gitlab.ReleaseLink, error)
-	CreateReleaseLink(tag string, name string, url string) (*gitlab.ReleaseLink, error)
-	DeleteReleaseLink(tag string, links *gitlab.ReleaseLink) error
+// ReleaseLinkSpec describes a release link to create or update. The link type
+// and direct asset path are left to GitLab when empty.
+type ReleaseLinkSpec struct {
+	Name            string
+	URL             string
+	LinkType        gitlab.LinkTypeValue
+	DirectAssetPath string
 }
 
 const (
@@ -48,31 +81,29 @@ const (
 type GitlabClient struct {
 	client *gitlab.Client
 
-	accessToken string
-	repository  string
-	gitlabHost  string
-	downloadAuths map[string]DownloadAuth
+	authType      string
+	username      string
+	accessToken   string
+	repository    string
+	project       *gitlab.Project
+	gitlabHost    string
+	gitlabOrigin  string
+	instanceURLs  *gitlabURLs
+	downloadAuths []*downloadAuth
+
+	transports        *transports
+	clientCertificate *tls.Certificate
+	operationTimeout  time.Duration
 }
 
 func NewGitLabClient(source Source) (*GitlabClient, error) {
-	var httpClient = &http.Client{}
-	var ctx = context.TODO()
-
-	if source.Insecure {
-		httpClient.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		// nolint:ineffassign,staticcheck
-		ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
-	}
-	httpClientOpt := gitlab.WithHTTPClient(httpClient)
-
 	baseURLOpt := gitlab.WithBaseURL(defaultBaseURL)
 	baseUrl, err := url.Parse(defaultBaseURL)
 	if err != nil {
 		return nil, err
 	}
 	gitlabHost := strings.ToLower(baseUrl.Hostname())
+	gitlabOrigin := urlOrigin(baseUrl)
 
 	if source.GitLabAPIURL != "" {
 		var err error
@@ -82,28 +113,85 @@ func NewGitLabClient(source Source) (*GitlabClient, error) {
 		}
 		baseURLOpt = gitlab.WithBaseURL(baseUrl.String())
 		gitlabHost = strings.ToLower(baseUrl.Hostname())
+		gitlabOrigin = urlOrigin(baseUrl)
+	}
+
+	transports, err := newTransports(source)
+	if err != nil {
+		return nil, err
+	}
+	if source.Insecure {
+		// insecure only ever applied to the GitLab host
+		transports.insecureHosts = append(transports.insecureHosts, gitlabHost)
+	}
+	clientCertificate, err := parseClientCertificate(source.ClientCert, source.ClientKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client_cert: %s", err)
+	}
+
+	var httpClient = &http.Client{
+		Transport: transports.get(gitlabHost, clientCertificate, nil),
 	}
+	httpClientOpt := gitlab.WithHTTPClient(httpClient)
 
-	client, err := gitlab.NewClient(source.AccessToken, httpClientOpt, baseURLOpt)
+	kind, err := authType(source)
+	if err != nil {
+		return nil, err
+	}
+	source.AccessToken, err = resolveAccessToken(source)
 	if err != nil {
 		return nil, err
 	}
 
-	auths := make(map[string]DownloadAuth)
-	for _, auth := range source.DownloadAuths {
-		auths[strings.ToLower(auth.Host)] = auth
+	// retries are handled by the shared transport
+	client, err := gitlab.NewAuthSourceClient(newAuthSource(kind, source), httpClientOpt, baseURLOpt, gitlab.WithoutRetries())
+	if err != nil {
+		return nil, err
+	}
+
+	auths, err := newDownloadAuths(source)
+	if err != nil {
+		return nil, err
+	}
+
+	repository, err := normalizeRepository(source.Repository)
+	if err != nil {
+		return nil, err
+	}
+	instanceURLs, err := newGitLabURLs(client.BaseURL().String())
+	if err != nil {
+		return nil, err
 	}
 
 	return &GitlabClient{
-		client:      client,
-		repository:  source.Repository,
-		accessToken: source.AccessToken,
-		downloadAuths: auths,
-		gitlabHost:    gitlabHost,
+		client:            client,
+		repository:        repository,
+		authType:          kind,
+		username:          source.Username,
+		accessToken:       source.AccessToken,
+		downloadAuths:     auths,
+		gitlabHost:        gitlabHost,
+		gitlabOrigin:      gitlabOrigin,
+		instanceURLs:      instanceURLs,
+		transports:        transports,
+		clientCertificate: clientCertificate,
+		operationTimeout:  time.Duration(source.OperationTimeout),
 	}, nil
 }
 
-func (g *GitlabClient) ListTags() ([]*gitlab.Tag, error) {
+// withTimeout bounds an API call by the operation timeout, if any. File
+// transfers are only bounded by the overall timeout.
+func (g *GitlabClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if g.operationTimeout == 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, g.operationTimeout)
+}
+
+func (g *GitlabClient) ListTags(ctx context.Context) ([]*gitlab.Tag, error) {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
 	var allTags []*gitlab.Tag
 
 	opt := &gitlab.ListTagsOptions{
@@ -116,9 +204,9 @@ func (g *GitlabClient) ListTags() ([]*gitlab.Tag, error) {
 	}
 
 	for {
-		tags, res, err := g.client.Tags.ListTags(g.repository, opt)
+		tags, res, err := g.client.Tags.ListTags(g.pid(), opt, gitlab.WithContext(ctx))
 		if err != nil {
-			return []*gitlab.Tag{}, err
+			return []*gitlab.Tag{}, apiError(res, err)
 		}
 
 		allTags = append(allTags, tags...)
@@ -133,7 +221,10 @@ func (g *GitlabClient) ListTags() ([]*gitlab.Tag, error) {
 	return allTags, nil
 }
 
-func (g *GitlabClient) ListReleases() ([]*gitlab.Release, error) {
+func (g *GitlabClient) ListReleases(ctx context.Context) ([]*gitlab.Release, error) {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
 	var allReleases []*gitlab.Release
 	opt := &gitlab.ListReleasesOptions{
 		ListOptions: gitlab.ListOptions{
@@ -143,9 +234,9 @@ func (g *GitlabClient) ListReleases() ([]*gitlab.Release, error) {
 	}
 
 	for {
-		releases, res, err := g.client.Releases.ListReleases(g.repository, opt)
+		releases, res, err := g.client.Releases.ListReleases(g.pid(), opt, gitlab.WithContext(ctx))
 		if err != nil {
-			return []*gitlab.Release{}, err
+			return []*gitlab.Release{}, apiError(res, err)
 		}
 		allReleases = append(allReleases, releases...)
 
@@ -158,7 +249,10 @@ func (g *GitlabClient) ListReleases() ([]*gitlab.Release, error) {
 	return allReleases, nil
 }
 
-func (g *GitlabClient) ListTagsUntil(tag_name string) ([]*gitlab.Tag, error) {
+func (g *GitlabClient) ListTagsUntil(ctx context.Context, tag_name string) ([]*gitlab.Tag, error) {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
 	var allTags []*gitlab.Tag
 
 	pageSize := 100
@@ -174,9 +268,9 @@ func (g *GitlabClient) ListTagsUntil(tag_name string) ([]*gitlab.Tag, error) {
 
 	var foundTag *gitlab.Tag
 	for {
-		tags, res, err := g.client.Tags.ListTags(g.repository, opt)
+		tags, res, err := g.client.Tags.ListTags(g.pid(), opt, gitlab.WithContext(ctx))
 		if err != nil {
-			return []*gitlab.Tag{}, err
+			return []*gitlab.Tag{}, apiError(res, err)
 		}
 
 		skipToNextPage := false
@@ -228,13 +322,13 @@ func (g *GitlabClient) ListTagsUntil(tag_name string) ([]*gitlab.Tag, error) {
 	return allTags, nil
 }
 
-func (g *GitlabClient) GetTag(tag_name string) (*gitlab.Tag, error) {
-	tag, resp, err := g.client.Tags.GetTag(g.repository, tag_name)
+func (g *GitlabClient) GetTag(ctx context.Context, tag_name string) (*gitlab.Tag, error) {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
+	tag, resp, err := g.client.Tags.GetTag(g.pid(), tag_name, gitlab.WithContext(ctx))
 	if err != nil {
-		if resp != nil && resp.StatusCode == http.StatusNotFound {
-			return nil, ErrNotFound
-		}
-		return nil, err
+		return nil, apiError(resp, err)
 	}
 
 	defer func(Body io.ReadCloser) {
@@ -246,62 +340,83 @@ func (g *GitlabClient) GetTag(tag_name string) (*gitlab.Tag, error) {
 	return tag, nil
 }
 
-func (g *GitlabClient) GetRelease(tag_name string) (*gitlab.Release, error) {
-	release, resp, err := g.client.Releases.GetRelease(g.repository, tag_name)
+func (g *GitlabClient) GetRelease(ctx context.Context, tag_name string) (*gitlab.Release, error) {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
+	release, resp, err := g.client.Releases.GetRelease(g.pid(), tag_name, gitlab.WithContext(ctx))
 	if err != nil {
-		if resp == nil {
-			return nil, err
-		}
-		switch resp.StatusCode {
-		case http.StatusForbidden:
-			return nil, ErrNotFound
-		case http.StatusNotFound:
-			return nil, ErrNotFound
-		default:
-			return nil, err
-		}
+		return nil, apiError(resp, err)
 	}
 
 	return release, nil
 }
 
-func (g *GitlabClient) CreateTag(tag_name string, ref string) (*gitlab.Tag, error) {
+func (g *GitlabClient) CreateTag(ctx context.Context, tag_name string, ref string) (*gitlab.Tag, error) {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
 	opt := &gitlab.CreateTagOptions{
 		TagName: gitlab.Ptr(tag_name),
 		Ref:     gitlab.Ptr(ref),
 		Message: gitlab.Ptr(tag_name),
 	}
 
-	tag, _, err := g.client.Tags.CreateTag(g.repository, opt)
+	tag, resp, err := g.client.Tags.CreateTag(g.pid(), opt, gitlab.WithContext(ctx))
 	if err != nil {
-		return &gitlab.Tag{}, err
+		return &gitlab.Tag{}, apiError(resp, err)
 	}
 
 	return tag, nil
 }
 
-func (g *GitlabClient) CreateRelease(name string, tag string, description *string) (*gitlab.Release, error) {
+func (g *GitlabClient) CreateRelease(ctx context.Context, name string, tag string, description *string) (*gitlab.Release, error) {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
 	opt := &gitlab.CreateReleaseOptions{
 		Name:        gitlab.Ptr(name),
 		TagName:     gitlab.Ptr(tag),
 		Description: description,
 	}
 
-	release, res, err := g.client.Releases.CreateRelease(g.repository, opt)
+	// https://docs.gitlab.com/ce/api/tags.html#create-a-new-release
+	// returns 409 if release already exists, reported as ErrConflict
+	release, resp, err := g.client.Releases.CreateRelease(g.pid(), opt, gitlab.WithContext(ctx))
 	if err != nil {
-		return &gitlab.Release{}, err
+		return &gitlab.Release{}, apiError(resp, err)
 	}
 
-	// https://docs.gitlab.com/ce/api/tags.html#create-a-new-release
-	// returns 409 if release already exists
-	if res.StatusCode == http.StatusConflict {
-		return nil, errors.New("release already exists")
+	return release, nil
+}
+
+// DeleteRelease deletes the release of the tag, but not the tag itself.
+func (g *GitlabClient) DeleteRelease(ctx context.Context, tag string) error {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
+	_, resp, err := g.client.Releases.DeleteRelease(g.pid(), tag, gitlab.WithContext(ctx))
+	if err != nil {
+		return apiError(resp, err)
 	}
+	return nil
+}
 
-	return release, nil
+func (g *GitlabClient) DeleteTag(ctx context.Context, tag string) error {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
+	resp, err := g.client.Tags.DeleteTag(g.pid(), tag, gitlab.WithContext(ctx))
+	if err != nil {
+		return apiError(resp, err)
+	}
+	return nil
 }
 
-func (g *GitlabClient) GetReleaseLinks(tag string) ([]*gitlab.ReleaseLink, error) {
+func (g *GitlabClient) GetReleaseLinks(ctx context.Context, tag string) ([]*gitlab.ReleaseLink, error) {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
 	links := []*gitlab.ReleaseLink{}
 	opt := &gitlab.ListReleaseLinksOptions{
 		ListOptions: gitlab.ListOptions{
@@ -310,9 +425,9 @@ func (g *GitlabClient) GetReleaseLinks(tag string) ([]*gitlab.ReleaseLink, error
 		},
 	}
 	for {
-		items, resp, err := g.client.ReleaseLinks.ListReleaseLinks(g.repository, tag, opt)
+		items, resp, err := g.client.ReleaseLinks.ListReleaseLinks(g.pid(), tag, opt, gitlab.WithContext(ctx))
 		if err != nil {
-			return nil, err
+			return nil, apiError(resp, err)
 		}
 
 		links = append(links, items...)
@@ -324,42 +439,239 @@ func (g *GitlabClient) GetReleaseLinks(tag string) ([]*gitlab.ReleaseLink, error
 	return links, nil
 }
 
-func (g *GitlabClient) DeleteReleaseLink(tag string, link *gitlab.ReleaseLink) error {
-	_, _, err := g.client.ReleaseLinks.DeleteReleaseLink(g.repository, tag, link.ID)
+func (g *GitlabClient) DeleteReleaseLink(ctx context.Context, tag string, link *gitlab.ReleaseLink) error {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
+	_, resp, err := g.client.ReleaseLinks.DeleteReleaseLink(g.pid(), tag, link.ID, gitlab.WithContext(ctx))
 	if err != nil {
-		return err
+		return apiError(resp, err)
 	}
 	return nil
 }
 
-func (g *GitlabClient) CreateReleaseLink(tag string, name string, url string) (*gitlab.ReleaseLink, error) {
+func (g *GitlabClient) CreateReleaseLink(ctx context.Context, tag string, spec ReleaseLinkSpec) (*gitlab.ReleaseLink, error) {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
 	opt := &gitlab.CreateReleaseLinkOptions{
-		Name: gitlab.Ptr(name),
-		URL:  gitlab.Ptr(url),
+		Name: gitlab.Ptr(spec.Name),
+		URL:  gitlab.Ptr(spec.URL),
 	}
-	link, _, err := g.client.ReleaseLinks.CreateReleaseLink(g.repository, tag, opt)
+	if spec.LinkType != "" {
+		opt.LinkType = gitlab.Ptr(spec.LinkType)
+	}
+	if spec.DirectAssetPath != "" {
+		opt.DirectAssetPath = gitlab.Ptr(spec.DirectAssetPath)
+	}
+	link, resp, err := g.client.ReleaseLinks.CreateReleaseLink(g.pid(), tag, opt, gitlab.WithContext(ctx))
 	if err != nil {
-		return nil, err
+		return nil, apiError(resp, err)
 	}
 
 	return link, nil
 }
 
-func (g *GitlabClient) UpdateRelease(name string, tag string, description *string) (*gitlab.Release, error) {
+func (g *GitlabClient) UpdateReleaseLink(ctx context.Context, tag string, link *gitlab.ReleaseLink, spec ReleaseLinkSpec) (*gitlab.ReleaseLink, error) {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
+	opt := &gitlab.UpdateReleaseLinkOptions{
+		URL: gitlab.Ptr(spec.URL),
+	}
+	if spec.LinkType != "" {
+		opt.LinkType = gitlab.Ptr(spec.LinkType)
+	}
+	if spec.DirectAssetPath != "" {
+		opt.DirectAssetPath = gitlab.Ptr(spec.DirectAssetPath)
+	}
+	updated, resp, err := g.client.ReleaseLinks.UpdateReleaseLink(g.pid(), tag, link.ID, opt, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, apiError(resp, err)
+	}
+
+	return updated, nil
+}
+
+func (g *GitlabClient) CompareRefs(ctx context.Context, from string, to string) (*gitlab.Compare, error) {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
+	opt := &gitlab.CompareOptions{
+		From: gitlab.Ptr(from),
+		To:   gitlab.Ptr(to),
+	}
+
+	compare, resp, err := g.client.Repositories.Compare(g.pid(), opt, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, apiError(resp, err)
+	}
+
+	return compare, nil
+}
+
+func (g *GitlabClient) ListMergeRequestsByCommit(ctx context.Context, sha string) ([]*gitlab.BasicMergeRequest, error) {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
+	mrs, resp, err := g.client.Commits.ListMergeRequestsByCommit(g.pid(), sha, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, apiError(resp, err)
+	}
+
+	return mrs, nil
+}
+
+func (g *GitlabClient) ListRepositoryTree(ctx context.Context, ref string) ([]*gitlab.TreeNode, error) {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
+	nodes := []*gitlab.TreeNode{}
+	opt := &gitlab.ListTreeOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: 100,
+			Page:    1,
+		},
+		Ref:       gitlab.Ptr(ref),
+		Recursive: gitlab.Ptr(true),
+	}
+
+	for {
+		items, resp, err := g.client.Repositories.ListTree(g.pid(), opt, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, apiError(resp, err)
+		}
+
+		nodes = append(nodes, items...)
+		if opt.Page >= resp.TotalPages {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return nodes, nil
+}
+
+// DownloadRepositoryFile streams the raw content of a repository file at the
+// given ref to destPath, resolving LFS pointers to their actual content.
+func (g *GitlabClient) DownloadRepositoryFile(ctx context.Context, filePath string, ref string, destPath string) error {
+	opt := &gitlab.GetRawFileOptions{
+		Ref: gitlab.Ptr(ref),
+		LFS: gitlab.Ptr(true),
+	}
+	u := fmt.Sprintf("projects/%s/repository/files/%s/raw", gitlab.PathEscape(fmt.Sprint(g.pid())), gitlab.PathEscape(filePath))
+	req, err := g.client.NewRequest(http.MethodGet, u, opt, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	resp, err := g.client.Do(req, out)
+	if closeErr := out.Close(); closeErr != nil && err == nil {
+		_ = os.Remove(destPath)
+		return closeErr
+	}
+	if err != nil {
+		// do not leave a partial file behind
+		_ = os.Remove(destPath)
+		err = apiError(resp, err)
+		if errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("repository file `%s` not found at `%s`: %w", filePath, ref, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// DownloadArchive streams the repository archive in the given format to
+// destPath, optionally restricted to subPath.
+func (g *GitlabClient) DownloadArchive(ctx context.Context, format string, sha string, subPath string, destPath string) error {
+	opt := &gitlab.ArchiveOptions{
+		Format: gitlab.Ptr(format),
+	}
+	if sha != "" {
+		opt.SHA = gitlab.Ptr(sha)
+	}
+	if subPath != "" {
+		opt.Path = gitlab.Ptr(subPath)
+	}
+
+	out, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	resp, err := g.client.Repositories.StreamArchive(g.pid(), out, opt, gitlab.WithContext(ctx))
+	if closeErr := out.Close(); closeErr != nil && err == nil {
+		_ = os.Remove(destPath)
+		return closeErr
+	}
+	if err != nil {
+		// do not leave a partial file behind
+		_ = os.Remove(destPath)
+		return fmt.Errorf("failed to download archive `%s`: %w", filepath.Base(destPath), apiError(resp, err))
+	}
+	return nil
+}
+
+func (g *GitlabClient) ListRegistryRepositories(ctx context.Context) ([]*gitlab.RegistryRepository, error) {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
+	repositories := []*gitlab.RegistryRepository{}
+	opt := &gitlab.ListProjectRegistryRepositoriesOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: 100,
+			Page:    1,
+		},
+	}
+
+	for {
+		items, resp, err := g.client.ContainerRegistry.ListProjectRegistryRepositories(g.pid(), opt, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, apiError(resp, err)
+		}
+
+		repositories = append(repositories, items...)
+		if opt.Page >= resp.TotalPages {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return repositories, nil
+}
+
+func (g *GitlabClient) GetRegistryRepositoryTag(ctx context.Context, repositoryID int64, tag string) (*gitlab.RegistryRepositoryTag, error) {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
+	registryTag, resp, err := g.client.ContainerRegistry.GetRegistryRepositoryTagDetail(g.pid(), repositoryID, tag, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, apiError(resp, err)
+	}
+
+	return registryTag, nil
+}
+
+func (g *GitlabClient) UpdateRelease(ctx context.Context, name string, tag string, description *string) (*gitlab.Release, error) {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
 	opt := &gitlab.UpdateReleaseOptions{
 		Name:        gitlab.Ptr(name),
 		Description: description,
 	}
 
-	release, _, err := g.client.Releases.UpdateRelease(g.repository, tag, opt)
+	release, resp, err := g.client.Releases.UpdateRelease(g.pid(), tag, opt, gitlab.WithContext(ctx))
 	if err != nil {
-		return &gitlab.Release{}, err
+		return &gitlab.Release{}, apiError(resp, err)
 	}
 
 	return release, nil
 }
 
-func (g *GitlabClient) UploadProjectFile(filepath string) (*gitlab.ProjectMarkdownUploadedFile, error) {
+func (g *GitlabClient) UploadProjectFile(ctx context.Context, filepath string) (*gitlab.ProjectMarkdownUploadedFile, error) {
 	reader, err := os.Open(filepath)
 	if err != nil {
 		return nil, err
@@ -367,79 +679,299 @@ func (g *GitlabClient) UploadProjectFile(filepath string) (*gitlab.ProjectMarkdo
 	defer func(reader *os.File) {
 		err := reader.Close()
 		if err != nil {
-			fmt.Printf("Error closing file reader: %s\n", err)
+			Sayf("Error closing file reader: %s\n", err)
 		}
 	}(reader)
 	filename := path.Base(filepath)
-	projectFile, _, err := g.client.ProjectMarkdownUploads.UploadProjectMarkdown(g.repository, reader, filename)
+	projectFile, resp, err := g.client.ProjectMarkdownUploads.UploadProjectMarkdown(g.pid(), reader, filename, gitlab.WithContext(ctx))
 	if err != nil {
-		return nil, err
+		return nil, apiError(resp, err)
 	}
 	return projectFile, nil
 }
 
-func (g *GitlabClient) DownloadProjectFile(fileURL, destPath string) error {
-	out, err := os.Create(destPath)
+// PublishPackageFile publishes the file to the generic package of the project,
+// and returns the API URL to download it from.
+func (g *GitlabClient) PublishPackageFile(ctx context.Context, packageName string, packageVersion string, file string) (string, error) {
+	reader, err := os.Open(file)
 	if err != nil {
-		return err
+		return "", err
 	}
-	defer func(out *os.File) {
-		err := out.Close()
+	defer func(reader *os.File) {
+		err := reader.Close()
 		if err != nil {
-			fmt.Printf("Error closing file: %s\n", err)
+			Sayf("Error closing file reader: %s\n", err)
 		}
-	}(out)
+	}(reader)
+	filename := path.Base(file)
+	_, resp, err := g.client.GenericPackages.PublishPackageFile(g.pid(), packageName, packageVersion, filename, reader, &gitlab.PublishPackageFileOptions{}, gitlab.WithContext(ctx))
+	if err != nil {
+		return "", apiError(resp, err)
+	}
+
+	// unlike FormatPackageURL, url.PathEscape keeps dots readable in links
+	packagePath := strings.Join([]string{
+		"projects", url.PathEscape(fmt.Sprint(g.pid())), "packages", "generic",
+		url.PathEscape(packageName), url.PathEscape(packageVersion), url.PathEscape(filename),
+	}, "/")
+	return g.urls().resolve("api/v4/") + packagePath, nil
+}
+
+// maxDownloadRedirects caps the redirects followed by a download.
+const maxDownloadRedirects = 10
 
-	// e.g. (baseURL) + (group/project) + (/uploads/hash/filename)
-	filePathRef, err := url.Parse(fileURL)
+// redirectChain lists the URLs visited by a download, without their query
+// strings which often carry signatures.
+type redirectChain []string
+
+func (c redirectChain) String() string {
+	return strings.Join(c, " -> ")
+}
+
+// describe returns the chain as a suffix for error messages, if redirected.
+func (c redirectChain) describe() string {
+	if len(c) < 2 {
+		return ""
+	}
+	return fmt.Sprintf(" (redirected through %s)", c)
+}
+
+func redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	redacted.RawQuery = ""
+	redacted.Fragment = ""
+	return redacted.String()
+}
+
+// urlOrigin returns the scheme and host:port of the URL, the port defaulting
+// to the one of the scheme.
+func urlOrigin(u *url.URL) string {
+	scheme := strings.ToLower(u.Scheme)
+	port := u.Port()
+	if port == "" {
+		switch scheme {
+		case "https":
+			port = "443"
+		case "http":
+			port = "80"
+		}
+	}
+	return scheme + "://" + net.JoinHostPort(strings.ToLower(u.Hostname()), port)
+}
+
+// doDownloadRequest sends a request for the file and follows redirects
+// explicitly, so that each hop only gets the credentials of its own origin:
+// the GitLab credentials for the GitLab origin, otherwise the most specific
+// matching download_auths entry. No credentials are sent anymore once
+// redirected from https to http.
+func (g *GitlabClient) doDownloadRequest(ctx context.Context, method string, fileURL string, header http.Header) (*http.Response, redirectChain, error) {
+	filePathRef, err := g.urls().downloadURL(fileURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chain := redirectChain{}
+	downgraded := false
+	for {
+		chain = append(chain, redactURL(filePathRef))
+		resp, err := g.sendDownloadRequest(ctx, method, filePathRef, header, !downgraded)
+		if err != nil {
+			return nil, chain, fmt.Errorf("%w%s", err, chain.describe())
+		}
+
+		switch resp.StatusCode {
+		case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+			http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		default:
+			return resp, chain, nil
+		}
+
+		location, err := resp.Location()
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, chain, fmt.Errorf("invalid redirect: %s%s", err, chain.describe())
+		}
+		if len(chain) > maxDownloadRedirects {
+			return nil, chain, fmt.Errorf("stopped after %d redirects%s", maxDownloadRedirects, chain.describe())
+		}
+		if strings.EqualFold(filePathRef.Scheme, "https") && !strings.EqualFold(location.Scheme, "https") {
+			downgraded = true
+		}
+		filePathRef = location
+	}
+}
+
+func (g *GitlabClient) sendDownloadRequest(ctx context.Context, method string, u *url.URL, header http.Header, authenticated bool) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	return g.hostClient(req, authenticated).Do(req)
+}
+
+// hostClient authenticates the request with the credentials of its origin,
+// unless authenticated is false: the GitLab credentials for the GitLab
+// origin, otherwise the most specific matching download_auths entry. It
+// returns a client that does not follow redirects.
+func (g *GitlabClient) hostClient(req *http.Request, authenticated bool) *http.Client {
+	var certificate *tls.Certificate
+	var proxy *url.URL
+	if urlOrigin(req.URL) == g.gitlabOrigin {
+		if authenticated {
+			authenticate(req, g.authType, g.username, g.accessToken)
+			certificate = g.clientCertificate
+		}
+	} else if auth := findDownloadAuth(g.downloadAuths, req.URL); auth != nil {
+		if authenticated {
+			auth.apply(req)
+			certificate = auth.certificate
+		}
+		proxy = auth.proxy
+	}
+
+	return &http.Client{
+		Transport: g.transports.get(req.URL.Hostname(), certificate, proxy),
+		// redirects are followed by doDownloadRequest
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// UploadObject stores the file at the URL of an S3-compatible object store,
+// signing the request with the credentials of the matching download_auths
+// entry.
+func (g *GitlabClient) UploadObject(ctx context.Context, objectURL string, file string) error {
+	digest, err := digestFile(file, sha256.New)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(file)
 	if err != nil {
 		return err
 	}
+	reader, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
 
-	// https://gitlab.com/gitlab-org/gitlab-ce/issues/51447
-	nonApiUrl := strings.Replace(filePathRef.String(), "/api/v4", "", 1)
-	filePathRef, err = url.Parse(nonApiUrl)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, reader)
 	if err != nil {
 		return err
 	}
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", filePathRef.String(), nil)
+	req.ContentLength = info.Size()
+	req.GetBody = func() (io.ReadCloser, error) {
+		return os.Open(file)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(digest))
+
+	resp, err := g.hostClient(req, true).Do(req)
 	if err != nil {
 		return err
 	}
-	downloadHost := strings.ToLower(filePathRef.Hostname())
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("failed to upload file `%s`: %w", filepath.Base(file), newRequestError(resp, s3ErrorMessage(body)))
+	}
+	return nil
+}
 
-	switch {
-	case downloadHost == g.gitlabHost:
-		// C'est une URL GitLab, utiliser le token
-		if g.accessToken != "" {
-			req.Header.Set("Private-Token", g.accessToken)
+// GetProjectFileSize returns the size announced by the asset host, or -1 when
+// it is unknown.
+func (g *GitlabClient) GetProjectFileSize(ctx context.Context, fileURL string) (int64, error) {
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
+	resp, _, err := g.doDownloadRequest(ctx, http.MethodHead, fileURL, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			Sayf("Error closing response body: %s\n", err)
 		}
-	case g.downloadAuths[downloadHost].Host != "":
-		// Use Basic Auth
-		auth := g.downloadAuths[downloadHost]
-		req.SetBasicAuth(auth.Username, auth.Password)
+	}(resp.Body)
+
+	// some hosts do not support HEAD requests, let the download decide
+	if resp.StatusCode != http.StatusOK {
+		return -1, nil
 	}
+	return resp.ContentLength, nil
+}
+
+// DownloadProjectFile downloads the asset to destPath and returns its size.
+// When maxSize is positive, the download fails as soon as it is exceeded.
+func (g *GitlabClient) DownloadProjectFile(ctx context.Context, fileURL string, destPath string, maxSize int64) (int64, error) {
+	_, written, err := g.downloadProjectFile(ctx, fileURL, destPath, maxSize, FileValidator{})
+	return written, err
+}
 
-	resp, err := client.Do(req)
+// DownloadProjectFileIfModified downloads the asset unless it still matches
+// the given validator, in which case ErrNotModified is returned and destPath
+// is left untouched. It returns the validator of the downloaded content.
+func (g *GitlabClient) DownloadProjectFileIfModified(ctx context.Context, fileURL string, destPath string, maxSize int64, validator FileValidator) (FileValidator, int64, error) {
+	return g.downloadProjectFile(ctx, fileURL, destPath, maxSize, validator)
+}
+
+func (g *GitlabClient) downloadProjectFile(ctx context.Context, fileURL string, destPath string, maxSize int64, validator FileValidator) (FileValidator, int64, error) {
+	header := http.Header{}
+	if validator.ETag != "" {
+		header.Set("If-None-Match", validator.ETag)
+	}
+	if validator.LastModified != "" {
+		header.Set("If-Modified-Since", validator.LastModified)
+	}
+
+	resp, chain, err := g.doDownloadRequest(ctx, http.MethodGet, fileURL, header)
 	if err != nil {
-		return err
+		return FileValidator{}, 0, err
 	}
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
 		if err != nil {
-			fmt.Printf("Error closing response body: %s\n", err)
+			Sayf("Error closing response body: %s\n", err)
 		}
 	}(resp.Body)
 
+	if resp.StatusCode == http.StatusNotModified && !validator.empty() {
+		return validator, 0, ErrNotModified
+	}
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download file `%s`: HTTP status %d", filepath.Base(destPath), resp.StatusCode)
+		return FileValidator{}, 0, fmt.Errorf("failed to download file `%s`: %w%s", filepath.Base(destPath), newRequestError(resp, ""), chain.describe())
+	}
+
+	if maxSize > 0 && resp.ContentLength > maxSize {
+		return FileValidator{}, 0, fmt.Errorf("failed to download file `%s`: %w (%d > %d bytes)", filepath.Base(destPath), ErrSizeLimitExceeded, resp.ContentLength, maxSize)
 	}
 
-	_, err = io.Copy(out, resp.Body)
+	out, err := os.Create(destPath)
 	if err != nil {
-		return err
+		return FileValidator{}, 0, err
+	}
+	written, err := io.Copy(out, &limitedReader{reader: resp.Body, limit: maxSize})
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// do not leave a partial file behind
+		_ = os.Remove(destPath)
+		if errors.Is(err, ErrSizeLimitExceeded) {
+			return FileValidator{}, written, fmt.Errorf("failed to download file `%s`: %w (more than %d bytes)", filepath.Base(destPath), ErrSizeLimitExceeded, maxSize)
+		}
+		return FileValidator{}, written, err
 	}
 
-	return nil
+	return FileValidator{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, written, nil
 }
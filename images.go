@@ -0,0 +1,153 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+type imageReference struct {
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// parseImageReference splits a container image reference such as
+// `registry.example.com/group/project/app:1.2.3` into its components.
+func parseImageReference(ref string) imageReference {
+	ref = strings.TrimPrefix(ref, "https://")
+	ref = strings.TrimPrefix(ref, "http://")
+
+	image := imageReference{}
+	if i := strings.Index(ref, "@"); i >= 0 {
+		image.Digest = ref[i+1:]
+		ref = ref[:i]
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		image.Tag = ref[i+1:]
+		ref = ref[:i]
+	}
+	if image.Tag == "" && image.Digest == "" {
+		image.Tag = "latest"
+	}
+	image.Repository = ref
+	return image
+}
+
+func (i imageReference) String() string {
+	ref := i.Repository
+	if i.Tag != "" {
+		ref += ":" + i.Tag
+	}
+	if i.Digest != "" {
+		ref += "@" + i.Digest
+	}
+	return ref
+}
+
+// imageHost returns the registry host of an image repository, Docker Hub
+// when the repository does not name one.
+func imageHost(repository string) string {
+	host, _, found := strings.Cut(repository, "/")
+	if !found || (!strings.ContainsAny(host, ".:") && host != "localhost") {
+		return "docker.io"
+	}
+	return host
+}
+
+type imageResolver struct {
+	gitlab       GitLab
+	writer       io.Writer
+	registry     *string
+	repositories []*gitlab.RegistryRepository
+}
+
+// registryHost returns the host of the project's container registry, or an
+// empty string when the project has none.
+func (r *imageResolver) registryHost(ctx context.Context) (string, error) {
+	if r.registry != nil {
+		return *r.registry, nil
+	}
+	project, err := r.gitlab.GetProject(ctx)
+	if err != nil {
+		return "", err
+	}
+	host := ""
+	if project != nil && project.ContainerRegistryImagePrefix != "" {
+		host = imageHost(project.ContainerRegistryImagePrefix)
+	}
+	r.registry = &host
+	return host, nil
+}
+
+// resolve pins the image to a digest when it is hosted in the project's
+// container registry. Images from other registries are returned as is, as are
+// images of a registry the token cannot read.
+func (r *imageResolver) resolve(ctx context.Context, ref string) (imageReference, error) {
+	image := parseImageReference(ref)
+	if image.Digest != "" {
+		return image, nil
+	}
+
+	host, err := r.registryHost(ctx)
+	if err != nil {
+		return r.unreadable(image, err)
+	}
+	if host == "" || !strings.EqualFold(imageHost(image.Repository), host) {
+		fmt.Fprintf(r.writer, "image '%s' is not hosted in the project container registry, keeping its tag\n", image)
+		return image, nil
+	}
+
+	if r.repositories == nil {
+		repositories, err := r.gitlab.ListRegistryRepositories(ctx)
+		if err != nil {
+			return r.unreadable(image, err)
+		}
+		r.repositories = repositories
+	}
+
+	for _, repository := range r.repositories {
+		if !strings.EqualFold(repository.Location, image.Repository) {
+			continue
+		}
+		tag, err := r.gitlab.GetRegistryRepositoryTag(ctx, repository.ID, image.Tag)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return imageReference{}, fmt.Errorf("image tag '%s' not found in container registry", image)
+			}
+			return imageReference{}, err
+		}
+		image.Digest = tag.Digest
+		return image, nil
+	}
+
+	fmt.Fprintf(r.writer, "image '%s' is not hosted in the project container registry, keeping its tag\n", image)
+	return image, nil
+}
+
+// unreadable keeps the tag of the image when the token cannot read the
+// project or its container registry, e.g. a job token or a disabled registry.
+func (r *imageResolver) unreadable(image imageReference, err error) (imageReference, error) {
+	if !errors.Is(err, ErrForbidden) && !errors.Is(err, ErrNotFound) {
+		return imageReference{}, err
+	}
+	fmt.Fprintf(r.writer, "cannot read the project container registry, keeping the tag of image '%s': %s\n", image, err)
+	return image, nil
+}
+
+// writeImage writes the image reference to images/<name> so that it can be
+// consumed by downstream resources.
+func writeImage(destDir string, name string, image imageReference) error {
+	imagesDir := filepath.Join(destDir, "images")
+	if err := os.MkdirAll(imagesDir, 0755); err != nil {
+		return err
+	}
+	name = strings.ReplaceAll(name, "/", "_")
+	return os.WriteFile(filepath.Join(imagesDir, name), []byte(image.String()), 0644)
+}
@@ -1,13 +1,22 @@
 package resource
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
 )
 
+// archiveFormats lists the formats supported by the repository archive API
+var archiveFormats = []string{"zip", "tar.gz", "tar.bz2", "tar"}
+
 type InCommand struct {
 	gitlab GitLab
 	writer io.Writer
@@ -42,7 +51,247 @@ func (c *InCommand) matchFormat(format string, formats []string) bool {
 	return false
 }
 
-func (c *InCommand) Run(destDir string, request InRequest) (InResponse, error) {
+// repositoryFilePaths resolves the given paths and globs against the
+// repository tree at ref. Literal paths are kept as is so they do not require
+// listing the tree.
+func (c *InCommand) repositoryFilePaths(ctx context.Context, ref string, patterns []string) ([]string, error) {
+	var tree []*gitlab.TreeNode
+	paths := []string{}
+	seen := map[string]bool{}
+
+	for _, entry := range patterns {
+		// keep paths relative to the repository root
+		pattern := strings.TrimPrefix(path.Clean("/"+entry), "/")
+		if pattern == "" {
+			return nil, fmt.Errorf("invalid repository file '%s'", entry)
+		}
+
+		if !strings.ContainsAny(pattern, "*?[") {
+			if !seen[pattern] {
+				seen[pattern] = true
+				paths = append(paths, pattern)
+			}
+			continue
+		}
+
+		if tree == nil {
+			var err error
+			tree, err = c.gitlab.ListRepositoryTree(ctx, ref)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		found := false
+		for _, node := range tree {
+			if node.Type != "blob" {
+				continue
+			}
+			matches, err := path.Match(pattern, node.Path)
+			if err != nil {
+				return nil, err
+			}
+			if !matches {
+				continue
+			}
+			found = true
+			if !seen[node.Path] {
+				seen[node.Path] = true
+				paths = append(paths, node.Path)
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("could not find repository file that matches glob '%s'", pattern)
+		}
+	}
+	return paths, nil
+}
+
+func (c *InCommand) downloadRepositoryFiles(ctx context.Context, destDir string, ref string, patterns []string) error {
+	paths, err := c.repositoryFilePaths(ctx, ref, patterns)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range paths {
+		destPath := filepath.Join(destDir, "repo", filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+			return err
+		}
+		if err := c.gitlab.DownloadRepositoryFile(ctx, p, ref, destPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// downloadArchives builds the source archives through the repository archive
+// API, which unlike the release sources can be restricted to a subpath.
+func (c *InCommand) downloadArchives(ctx context.Context, destDir string, release *gitlab.Release, formats []string, params InParams) error {
+	sha := params.SourceSHA
+	if sha == "" {
+		sha = release.TagName
+	}
+	if len(formats) == 0 {
+		formats = []string{"tar.gz"}
+	}
+
+	for _, format := range formats {
+		if !c.matchFormat(format, archiveFormats) {
+			return fmt.Errorf("unsupported source format '%s'", format)
+		}
+
+		destPath := filepath.Join(destDir, "source."+format)
+		err := c.gitlab.DownloadArchive(ctx, format, sha, params.SourcePath, destPath)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSizes enforces the size limits, if any, from the sizes announced by
+// the asset hosts and makes sure the assets fit on disk, before downloading
+// anything.
+func (c *InCommand) checkSizes(ctx context.Context, destDir string, cache *downloadCache, assets []*gitlab.ReleaseLink, params InParams) error {
+	maxAssetSize, maxTotalSize := int64(params.MaxAssetSize), int64(params.MaxTotalSize)
+
+	totalSize := int64(0)
+	for _, asset := range assets {
+		size, err := c.gitlab.GetProjectFileSize(ctx, asset.URL)
+		if err != nil {
+			return err
+		}
+		// unknown size, enforced while downloading
+		if size < 0 {
+			continue
+		}
+
+		if maxAssetSize > 0 && size > maxAssetSize {
+			return fmt.Errorf("asset '%s' exceeds max_asset_size of %d bytes: %d bytes", asset.Name, maxAssetSize, size)
+		}
+		totalSize += size
+		if maxTotalSize > 0 && totalSize > maxTotalSize {
+			return fmt.Errorf("asset '%s' exceeds max_total_size of %d bytes: %d bytes in total", asset.Name, maxTotalSize, totalSize)
+		}
+	}
+
+	// with a cache, assets are downloaded into it, then linked or copied
+	// into the destination
+	dirs := []string{destDir}
+	if cache != nil {
+		dirs = append([]string{cache.dir}, dirs...)
+	}
+	for _, dir := range dirs {
+		free, err := freeDiskSpace(dir)
+		if err != nil {
+			return err
+		}
+		if free >= 0 && totalSize > free {
+			return fmt.Errorf("not enough disk space in '%s': %d bytes needed, %d bytes available", dir, totalSize, free)
+		}
+	}
+	return nil
+}
+
+// verifySignature locates the companion signature asset of the downloaded
+// file by naming convention, among the types a trusted key is configured for,
+// and verifies it against the trusted keys.
+func (c *InCommand) verifySignature(ctx context.Context, verifier *signatureVerifier, release *gitlab.Release, asset *gitlab.ReleaseLink, file string) (string, error) {
+	suffixes := verifier.suffixes()
+	var signature *gitlab.ReleaseLink
+	for _, suffix := range suffixes {
+		for _, link := range release.Assets.Links {
+			if link.Name == asset.Name+suffix {
+				signature = link
+				break
+			}
+		}
+		if signature != nil {
+			break
+		}
+	}
+	if signature == nil {
+		return "", fmt.Errorf("no signature found, expected one of %s", strings.Join(suffixes, ", "))
+	}
+
+	tmpDir, err := os.MkdirTemp("", "gitlab-release-signature")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	signatureFile := filepath.Join(tmpDir, filepath.Base(signature.Name))
+	if _, err := c.gitlab.DownloadProjectFile(ctx, signature.URL, signatureFile, 0); err != nil {
+		return "", err
+	}
+	return verifier.verify(file, signatureFile)
+}
+
+// openCache opens the download cache given by the cache_dir param or the
+// RESOURCE_CACHE_DIR environment variable, if any.
+func (c *InCommand) openCache(params InParams) (*downloadCache, error) {
+	dir := params.CacheDir
+	if dir == "" {
+		dir = os.Getenv("RESOURCE_CACHE_DIR")
+	}
+	if dir == "" {
+		return nil, nil
+	}
+	return newDownloadCache(dir, int64(params.CacheMaxSize), time.Duration(params.CacheMaxAge), params.CacheHardLinks)
+}
+
+// downloadAsset downloads the asset to destPath and returns its size. With a
+// cache, the cached copy is revalidated with a conditional request and only
+// downloaded again when it changed.
+func (c *InCommand) downloadAsset(ctx context.Context, cache *downloadCache, asset *gitlab.ReleaseLink, destPath string, maxSize int64) (int64, error) {
+	if cache == nil {
+		return c.gitlab.DownloadProjectFile(ctx, asset.URL, destPath, maxSize)
+	}
+
+	// evicting the cache waits until the asset is linked
+	release, err := cache.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	// links published by out record the checksum of the asset
+	entry := cache.lookup(asset.URL, linkChecksum(asset.URL))
+	validator := FileValidator{}
+	if entry != nil {
+		validator = entry.Validator
+	}
+
+	tmp, err := cache.tempFile()
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp)
+
+	validator, _, err = c.gitlab.DownloadProjectFileIfModified(ctx, asset.URL, tmp, maxSize, validator)
+	switch {
+	case errors.Is(err, ErrNotModified) && entry != nil:
+		if maxSize > 0 && entry.Size > maxSize {
+			return 0, fmt.Errorf("failed to download file `%s`: %w (%d > %d bytes)", filepath.Base(destPath), ErrSizeLimitExceeded, entry.Size, maxSize)
+		}
+		fmt.Fprintf(c.writer, "using cached copy of asset '%s'\n", asset.Name)
+	case err != nil:
+		return 0, err
+	default:
+		entry, err = cache.store(asset.URL, tmp, validator)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := cache.touch(entry); err != nil {
+		return 0, err
+	}
+	return entry.Size, cache.link(entry, destPath)
+}
+
+func (c *InCommand) Run(ctx context.Context, destDir string, request InRequest) (InResponse, error) {
 	err := os.MkdirAll(destDir, 0755)
 	if err != nil {
 		return InResponse{}, err
@@ -56,7 +305,7 @@ func (c *InCommand) Run(destDir string, request InRequest) (InResponse, error) {
 		return InResponse{}, errors.New("missing required Version Tag")
 	}
 
-	release, err := c.gitlab.GetRelease(request.Version.Tag)
+	release, err := c.gitlab.GetRelease(ctx, request.Version.Tag)
 	if err != nil {
 		if errors.Is(err, ErrNotFound) {
 			return InResponse{}, errors.New("no releases")
@@ -94,13 +343,136 @@ func (c *InCommand) Run(destDir string, request InRequest) (InResponse, error) {
 		return InResponse{}, err
 	}
 
+	if request.Params.IncludeChangelog {
+		releases, err := c.gitlab.ListReleases(ctx)
+		if err != nil {
+			return InResponse{}, err
+		}
+		previous := previousRelease(releases, release.TagName, versionParser)
+		changelog, err := buildChangelog(ctx, c.gitlab, previous, release)
+		if err != nil {
+			return InResponse{}, err
+		}
+		err = changelog.write(destDir)
+		if err != nil {
+			return InResponse{}, err
+		}
+	}
+
+	renamer, err := newAssetRenamer(request.Params.Rename, request.Params.OutputDirTemplate, templateData{
+		Version: version,
+		Tag:     release.TagName,
+	})
+	if err != nil {
+		return InResponse{}, err
+	}
+
+	metadata := metadataFromRelease(release)
+	images := &imageResolver{gitlab: c.gitlab, writer: c.writer}
+	assets := []*gitlab.ReleaseLink{}
+	destPaths := []string{}
+	renamedFrom := map[string]string{}
 	for _, asset := range release.Assets.Links {
-		destPath := filepath.Join(destDir, asset.Name)
 		if !c.matchAsset(asset.Name, request.Params.Globs) {
 			continue
 		}
 
-		err := c.gitlab.DownloadProjectFile(asset.URL, destPath)
+		if asset.LinkType == gitlab.ImageLinkType {
+			image, err := images.resolve(ctx, asset.URL)
+			if err != nil {
+				return InResponse{}, err
+			}
+			if err := writeImage(destDir, asset.Name, image); err != nil {
+				return InResponse{}, err
+			}
+			metadata = append(metadata, MetadataPair{
+				Name:  "image:" + asset.Name,
+				Value: image.String(),
+			})
+			continue
+		}
+
+		name, err := renamer.rename(asset.Name)
+		if err != nil {
+			return InResponse{}, fmt.Errorf("cannot rename asset '%s': %s", asset.Name, err)
+		}
+		// detect conflicts before downloading anything
+		if other, ok := renamedFrom[name]; ok {
+			return InResponse{}, fmt.Errorf("assets '%s' and '%s' are both renamed to '%s'", other, asset.Name, filepath.ToSlash(name))
+		}
+		renamedFrom[name] = asset.Name
+		assets = append(assets, asset)
+		destPaths = append(destPaths, filepath.Join(destDir, name))
+	}
+
+	var verifier *signatureVerifier
+	if request.Params.VerifySignatures {
+		verifier, err = newSignatureVerifier(request.Source.SignatureKeys)
+		if err != nil {
+			return InResponse{}, err
+		}
+	}
+
+	cache, err := c.openCache(request.Params)
+	if err != nil {
+		return InResponse{}, err
+	}
+
+	err = c.checkSizes(ctx, destDir, cache, assets, request.Params)
+	if err != nil {
+		return InResponse{}, err
+	}
+
+	maxAssetSize, maxTotalSize := int64(request.Params.MaxAssetSize), int64(request.Params.MaxTotalSize)
+	totalSize := int64(0)
+	for i, asset := range assets {
+		if err := os.MkdirAll(filepath.Dir(destPaths[i]), 0755); err != nil {
+			return InResponse{}, err
+		}
+
+		maxSize := maxAssetSize
+		if maxTotalSize > 0 {
+			remaining := maxTotalSize - totalSize
+			if remaining <= 0 {
+				return InResponse{}, fmt.Errorf("asset '%s' exceeds max_total_size of %d bytes", asset.Name, maxTotalSize)
+			}
+			if maxSize == 0 || remaining < maxSize {
+				maxSize = remaining
+			}
+		}
+		size, err := c.downloadAsset(ctx, cache, asset, destPaths[i], maxSize)
+		if err != nil {
+			if errors.Is(err, ErrSizeLimitExceeded) {
+				return InResponse{}, fmt.Errorf("asset '%s' exceeds the size limits: %w", asset.Name, err)
+			}
+			return InResponse{}, err
+		}
+		totalSize += size
+
+		if verifier != nil && !isSignature(asset.Name) {
+			signer, err := c.verifySignature(ctx, verifier, release, asset, destPaths[i])
+			if err != nil {
+				return InResponse{}, fmt.Errorf("signature verification failed for asset '%s': %w", asset.Name, err)
+			}
+			metadata = append(metadata, MetadataPair{
+				Name:  "signature:" + asset.Name,
+				Value: "verified with " + signer,
+			})
+		}
+	}
+
+	if cache != nil {
+		// a failed eviction must not fail the get
+		evicted, err := cache.evict()
+		if err != nil {
+			fmt.Fprintf(c.writer, "failed to evict cached assets: %s\n", err)
+		} else if !evicted {
+			fmt.Fprintf(c.writer, "cache in use by other gets, eviction skipped\n")
+		}
+	}
+
+	if len(request.Params.RepositoryFiles) > 0 {
+		err := c.downloadRepositoryFiles(ctx, destDir, release.TagName, request.Params.RepositoryFiles)
 		if err != nil {
 			return InResponse{}, err
 		}
@@ -116,21 +488,27 @@ func (c *InCommand) Run(destDir string, request InRequest) (InResponse, error) {
 		}
 	}
 
-	for _, source := range release.Assets.Sources {
-		if !c.matchFormat(source.Format, sources) {
-			continue
-		}
-
-		name := path.Base(source.URL)
-		destPath := filepath.Join(destDir, name)
-		err := c.gitlab.DownloadProjectFile(source.URL, destPath)
+	if request.Params.SourcePath != "" || request.Params.SourceSHA != "" {
+		err := c.downloadArchives(ctx, destDir, release, sources, request.Params)
 		if err != nil {
 			return InResponse{}, err
 		}
+	} else {
+		for _, source := range release.Assets.Sources {
+			if !c.matchFormat(source.Format, sources) {
+				continue
+			}
+
+			destPath := filepath.Join(destDir, "source."+source.Format)
+			_, err := c.gitlab.DownloadProjectFile(ctx, source.URL, destPath, 0)
+			if err != nil {
+				return InResponse{}, err
+			}
+		}
 	}
 
 	return InResponse{
 		Version:  versionFromRelease(release),
-		Metadata: metadataFromRelease(release),
+		Metadata: metadata,
 	}, nil
 }
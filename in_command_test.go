@@ -1,12 +1,18 @@
 package resource_test
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path"
 	"path/filepath"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -36,7 +42,7 @@ var _ = Describe("In Command", func() {
 		tmpDir, err = os.MkdirTemp("", "gitlab-release")
 		Ω(err).ShouldNot(HaveOccurred())
 		destDir = filepath.Join(tmpDir, "destination")
-		gitlabClient.DownloadProjectFileReturns(nil)
+		gitlabClient.DownloadProjectFileReturns(0, nil)
 		inRequest = resource.InRequest{}
 		inResponse = resource.InResponse{}
 	})
@@ -88,12 +94,12 @@ var _ = Describe("In Command", func() {
 			})
 
 			It("succeeds", func() {
-				inResponse, inErr = command.Run(destDir, inRequest)
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
 				Ω(inErr).ShouldNot(HaveOccurred())
 			})
 
 			It("in answer with version", func() {
-				inResponse, inErr = command.Run(destDir, inRequest)
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
 				Ω(inErr).ShouldNot(HaveOccurred())
 				Ω(inResponse.Version).Should(Equal(resource.Version{
 					Tag:       "v0.35.0",
@@ -102,7 +108,7 @@ var _ = Describe("In Command", func() {
 			})
 
 			It("with sweet metadata", func() {
-				inResponse, inErr = command.Run(destDir, inRequest)
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
 				Ω(inErr).ShouldNot(HaveOccurred())
 				Ω(inResponse.Metadata).Should(ConsistOf([]resource.MetadataPair{
 					{Name: "name", Value: "v0.35.0"},
@@ -113,27 +119,28 @@ var _ = Describe("In Command", func() {
 			})
 
 			It("calls #GetRelease with the correct arguments", func() {
-				inResponse, inErr = command.Run(destDir, inRequest)
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
 				Ω(inErr).ShouldNot(HaveOccurred())
 				Ω(gitlabClient.GetReleaseCallCount()).Should(Equal(1))
-				Ω(gitlabClient.GetReleaseArgsForCall(0)).Should(Equal("v0.35.0"))
+				_, tag := gitlabClient.GetReleaseArgsForCall(0)
+				Ω(tag).Should(Equal("v0.35.0"))
 			})
 
 			It("downloads only the files that match the globs", func() {
-				inResponse, inErr = command.Run(destDir, inRequest)
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
 				Ω(inErr).ShouldNot(HaveOccurred())
 
 				Expect(gitlabClient.DownloadProjectFileCallCount()).To(Equal(2))
-				arg1, arg2 := gitlabClient.DownloadProjectFileArgsForCall(0)
+				_, arg1, arg2, _ := gitlabClient.DownloadProjectFileArgsForCall(0)
 				Ω(arg1).Should(Equal("example.txt"))
 				Ω(arg2).Should(Equal(path.Join(destDir, "example.txt")))
-				arg1, arg2 = gitlabClient.DownloadProjectFileArgsForCall(1)
+				_, arg1, arg2, _ = gitlabClient.DownloadProjectFileArgsForCall(1)
 				Ω(arg1).Should(Equal("example.rtf"))
 				Ω(arg2).Should(Equal(path.Join(destDir, "example.rtf")))
 			})
 
 			It("does create the body, tag and version files", func() {
-				inResponse, inErr = command.Run(destDir, inRequest)
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
 
 				contents, err := os.ReadFile(path.Join(destDir, "tag"))
 				Ω(err).ShouldNot(HaveOccurred())
@@ -161,12 +168,12 @@ var _ = Describe("In Command", func() {
 				})
 
 				It("succeeds", func() {
-					inResponse, inErr = command.Run(destDir, inRequest)
+					inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
 					Expect(inErr).ToNot(HaveOccurred())
 				})
 
 				It("does create the body, tag and version files", func() {
-					inResponse, inErr = command.Run(destDir, inRequest)
+					inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
 					contents, err := os.ReadFile(path.Join(destDir, "tag"))
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(string(contents)).Should(Equal("package-0.35.0"))
@@ -181,7 +188,7 @@ var _ = Describe("In Command", func() {
 		Context("when no globs are specified", func() {
 			BeforeEach(func() {
 				inRequest.Params.Globs = []string{}
-				inResponse, inErr = command.Run(destDir, inRequest)
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
 			})
 
 			It("succeeds", func() {
@@ -197,15 +204,15 @@ var _ = Describe("In Command", func() {
 			It("downloads all of the files", func() {
 				Expect(gitlabClient.DownloadProjectFileCallCount()).To(Equal(3))
 
-				arg1, arg2 := gitlabClient.DownloadProjectFileArgsForCall(0)
+				_, arg1, arg2, _ := gitlabClient.DownloadProjectFileArgsForCall(0)
 				Ω(arg1).Should(Equal("example.txt"))
 				Ω(arg2).Should(Equal(path.Join(destDir, "example.txt")))
 
-				arg1, arg2 = gitlabClient.DownloadProjectFileArgsForCall(1)
+				_, arg1, arg2, _ = gitlabClient.DownloadProjectFileArgsForCall(1)
 				Ω(arg1).Should(Equal("example.rtf"))
 				Ω(arg2).Should(Equal(path.Join(destDir, "example.rtf")))
 
-				arg1, arg2 = gitlabClient.DownloadProjectFileArgsForCall(2)
+				_, arg1, arg2, _ = gitlabClient.DownloadProjectFileArgsForCall(2)
 				Ω(arg1).Should(Equal("example.png"))
 				Ω(arg2).Should(Equal(path.Join(destDir, "example.png")))
 			})
@@ -222,22 +229,22 @@ var _ = Describe("In Command", func() {
 				})
 
 				It("downloads all the available sources", func() {
-					inResponse, inErr = command.Run(destDir, inRequest)
+					inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
 					Ω(inErr).ShouldNot(HaveOccurred())
 
 					Expect(gitlabClient.DownloadProjectFileCallCount()).To(Equal(4))
-					arg1, arg2 := gitlabClient.DownloadProjectFileArgsForCall(0)
+					_, arg1, arg2, _ := gitlabClient.DownloadProjectFileArgsForCall(0)
 					Ω(arg1).Should(Equal("sources.zip"))
-					Ω(arg2).Should(Equal(path.Join(destDir, "sources.zip")))
-					arg1, arg2 = gitlabClient.DownloadProjectFileArgsForCall(1)
+					Ω(arg2).Should(Equal(path.Join(destDir, "source.zip")))
+					_, arg1, arg2, _ = gitlabClient.DownloadProjectFileArgsForCall(1)
 					Ω(arg1).Should(Equal("sources.tar.gz"))
-					Ω(arg2).Should(Equal(path.Join(destDir, "sources.tar.gz")))
-					arg1, arg2 = gitlabClient.DownloadProjectFileArgsForCall(2)
+					Ω(arg2).Should(Equal(path.Join(destDir, "source.tar.gz")))
+					_, arg1, arg2, _ = gitlabClient.DownloadProjectFileArgsForCall(2)
 					Ω(arg1).Should(Equal("sources.tar.bz2"))
-					Ω(arg2).Should(Equal(path.Join(destDir, "sources.tar.bz2")))
-					arg1, arg2 = gitlabClient.DownloadProjectFileArgsForCall(3)
+					Ω(arg2).Should(Equal(path.Join(destDir, "source.tar.bz2")))
+					_, arg1, arg2, _ = gitlabClient.DownloadProjectFileArgsForCall(3)
 					Ω(arg1).Should(Equal("sources.tar"))
-					Ω(arg2).Should(Equal(path.Join(destDir, "sources.tar")))
+					Ω(arg2).Should(Equal(path.Join(destDir, "source.tar")))
 				})
 			})
 
@@ -246,15 +253,15 @@ var _ = Describe("In Command", func() {
 					inRequest.Params.IncludeSources = []string{"tar.bz2", "tar.gz"}
 				})
 				It("downloads only the requested source formats", func() {
-					inResponse, inErr = command.Run(destDir, inRequest)
+					inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
 					Ω(inErr).ShouldNot(HaveOccurred())
 					Expect(gitlabClient.DownloadProjectFileCallCount()).To(Equal(2))
-					arg1, arg2 := gitlabClient.DownloadProjectFileArgsForCall(0)
+					_, arg1, arg2, _ := gitlabClient.DownloadProjectFileArgsForCall(0)
 					Ω(arg1).Should(Equal("sources.tar.gz"))
-					Ω(arg2).Should(Equal(path.Join(destDir, "sources.tar.gz")))
-					arg1, arg2 = gitlabClient.DownloadProjectFileArgsForCall(1)
+					Ω(arg2).Should(Equal(path.Join(destDir, "source.tar.gz")))
+					_, arg1, arg2, _ = gitlabClient.DownloadProjectFileArgsForCall(1)
 					Ω(arg1).Should(Equal("sources.tar.bz2"))
-					Ω(arg2).Should(Equal(path.Join(destDir, "sources.tar.bz2")))
+					Ω(arg2).Should(Equal(path.Join(destDir, "source.tar.bz2")))
 				})
 			})
 
@@ -263,12 +270,57 @@ var _ = Describe("In Command", func() {
 					inRequest.Params.IncludeSourceTarball = true
 				})
 				It("downloads only the requested source formats", func() {
-					inResponse, inErr = command.Run(destDir, inRequest)
+					inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
 					Ω(inErr).ShouldNot(HaveOccurred())
 					Expect(gitlabClient.DownloadProjectFileCallCount()).To(Equal(1))
-					arg1, arg2 := gitlabClient.DownloadProjectFileArgsForCall(0)
+					_, arg1, arg2, _ := gitlabClient.DownloadProjectFileArgsForCall(0)
 					Ω(arg1).Should(Equal("sources.tar.gz"))
-					Ω(arg2).Should(Equal(path.Join(destDir, "sources.tar.gz")))
+					Ω(arg2).Should(Equal(path.Join(destDir, "source.tar.gz")))
+				})
+			})
+
+			Context("restricted to a subpath", func() {
+				BeforeEach(func() {
+					inRequest.Params.IncludeSources = []string{"zip", "tar.gz"}
+					inRequest.Params.SourcePath = "services/api"
+				})
+
+				It("downloads archives of the subpath at the release tag", func() {
+					inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+					Ω(inErr).ShouldNot(HaveOccurred())
+					Ω(gitlabClient.DownloadProjectFileCallCount()).Should(Equal(0))
+					Ω(gitlabClient.DownloadArchiveCallCount()).Should(Equal(2))
+					_, format, sha, subPath, destPath := gitlabClient.DownloadArchiveArgsForCall(0)
+					Ω(format).Should(Equal("zip"))
+					Ω(sha).Should(Equal("v0.35.0"))
+					Ω(subPath).Should(Equal("services/api"))
+					Ω(destPath).Should(Equal(path.Join(destDir, "source.zip")))
+					_, format, _, _, destPath = gitlabClient.DownloadArchiveArgsForCall(1)
+					Ω(format).Should(Equal("tar.gz"))
+					Ω(destPath).Should(Equal(path.Join(destDir, "source.tar.gz")))
+				})
+
+				It("defaults to a tarball when no format is given", func() {
+					inRequest.Params.IncludeSources = nil
+					inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+					Ω(inErr).ShouldNot(HaveOccurred())
+					Ω(gitlabClient.DownloadArchiveCallCount()).Should(Equal(1))
+					_, format, _, _, _ := gitlabClient.DownloadArchiveArgsForCall(0)
+					Ω(format).Should(Equal("tar.gz"))
+				})
+
+				It("uses the given sha", func() {
+					inRequest.Params.SourceSHA = "deadbeef"
+					inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+					Ω(inErr).ShouldNot(HaveOccurred())
+					_, _, sha, _, _ := gitlabClient.DownloadArchiveArgsForCall(0)
+					Ω(sha).Should(Equal("deadbeef"))
+				})
+
+				It("rejects unsupported formats", func() {
+					inRequest.Params.IncludeSources = []string{"rar"}
+					inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+					Ω(inErr).Should(MatchError("unsupported source format 'rar'"))
 				})
 			})
 
@@ -277,20 +329,603 @@ var _ = Describe("In Command", func() {
 					inRequest.Params.IncludeSourceZip = true
 				})
 				It("downloads only the requested source formats", func() {
-					inResponse, inErr = command.Run(destDir, inRequest)
+					inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
 					Ω(inErr).ShouldNot(HaveOccurred())
 					Expect(gitlabClient.DownloadProjectFileCallCount()).To(Equal(1))
-					arg1, arg2 := gitlabClient.DownloadProjectFileArgsForCall(0)
+					_, arg1, arg2, _ := gitlabClient.DownloadProjectFileArgsForCall(0)
 					Ω(arg1).Should(Equal("sources.zip"))
-					Ω(arg2).Should(Equal(path.Join(destDir, "sources.zip")))
+					Ω(arg2).Should(Equal(path.Join(destDir, "source.zip")))
+				})
+			})
+		})
+
+		Context("when the changelog is requested", func() {
+			BeforeEach(func() {
+				inRequest.Params.Globs = []string{"does-not-match"}
+				inRequest.Params.IncludeChangelog = true
+				gitlabClient.ListReleasesReturns([]*gitlab.Release{
+					buildRelease("v0.36.0", "fff000"),
+					buildRelease("v0.34.0", "ddd000"),
+					buildRelease("v0.35.0", "abc123"),
+					buildRelease("v0.9.0", "aaa000"),
+				}, nil)
+				gitlabClient.CompareRefsReturns(&gitlab.Compare{
+					WebURL: "https://gitlab.com/group/project/-/compare/v0.34.0...v0.35.0",
+					Commits: []*gitlab.Commit{
+						{ID: "abc123", ShortID: "abc1", Title: "Add feature", AuthorName: "Jane Doe"},
+						{ID: "bcd234", ShortID: "bcd2", Title: "Merge branch 'feature'", AuthorName: "Jane Doe"},
+					},
+				}, nil)
+				gitlabClient.ListMergeRequestsByCommitReturns([]*gitlab.BasicMergeRequest{
+					{
+						IID:    12,
+						Title:  "Add feature",
+						State:  "merged",
+						WebURL: "https://gitlab.com/group/project/-/merge_requests/12",
+						Author: &gitlab.BasicUser{Username: "jdoe"},
+						Labels: gitlab.Labels{"feature"},
+					},
+					{IID: 13, Title: "Abandoned", State: "closed"},
+				}, nil)
+			})
+
+			It("compares with the previous release in version order", func() {
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				Ω(gitlabClient.CompareRefsCallCount()).Should(Equal(1))
+				_, from, to := gitlabClient.CompareRefsArgsForCall(0)
+				Ω(from).Should(Equal("v0.34.0"))
+				Ω(to).Should(Equal("v0.35.0"))
+				Ω(gitlabClient.ListMergeRequestsByCommitCallCount()).Should(Equal(2))
+				_, sha := gitlabClient.ListMergeRequestsByCommitArgsForCall(0)
+				Ω(sha).Should(Equal("abc123"))
+			})
+
+			It("orders the releases by tag, as check does", func() {
+				previous := buildRelease("v0.34.0", "ddd000")
+				previous.Name = "v0.10.0"
+				older := buildRelease("v0.30.0", "ccc000")
+				older.Name = "v0.34.9"
+				gitlabClient.ListReleasesReturns([]*gitlab.Release{older, previous, buildRelease("v0.35.0", "abc123")}, nil)
+
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				_, from, _ := gitlabClient.CompareRefsArgsForCall(0)
+				Ω(from).Should(Equal("v0.34.0"))
+			})
+
+			It("writes the changes files", func() {
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+
+				contents, err := os.ReadFile(path.Join(destDir, "changes.json"))
+				Ω(err).ShouldNot(HaveOccurred())
+				changelog := resource.Changelog{}
+				Ω(json.Unmarshal(contents, &changelog)).Should(Succeed())
+				Ω(changelog.From).Should(Equal("v0.34.0"))
+				Ω(changelog.To).Should(Equal("v0.35.0"))
+				Ω(changelog.Commits).Should(HaveLen(2))
+				Ω(changelog.MergeRequests).Should(Equal([]resource.ChangelogMergeRequest{{
+					IID:    12,
+					Title:  "Add feature",
+					Author: "jdoe",
+					URL:    "https://gitlab.com/group/project/-/merge_requests/12",
+					Labels: []string{"feature"},
+				}}))
+
+				contents, err = os.ReadFile(path.Join(destDir, "changes.md"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(contents)).Should(ContainSubstring("## Changes from v0.34.0 to v0.35.0"))
+				Ω(string(contents)).Should(ContainSubstring("* [!12](https://gitlab.com/group/project/-/merge_requests/12) Add feature (@jdoe) `feature`"))
+				Ω(string(contents)).Should(ContainSubstring("* abc1 Add feature (Jane Doe)"))
+			})
+
+			It("bounds the merge request lookups of large ranges", func() {
+				commits := []*gitlab.Commit{}
+				for i := 0; i < 150; i++ {
+					commits = append(commits, &gitlab.Commit{ID: fmt.Sprintf("%040d", i)})
+				}
+				gitlabClient.CompareRefsReturns(&gitlab.Compare{Commits: commits}, nil)
+
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				Ω(gitlabClient.ListMergeRequestsByCommitCallCount()).Should(Equal(100))
+
+				contents, err := os.ReadFile(path.Join(destDir, "changes.json"))
+				Ω(err).ShouldNot(HaveOccurred())
+				changelog := resource.Changelog{}
+				Ω(json.Unmarshal(contents, &changelog)).Should(Succeed())
+				Ω(changelog.Commits).Should(HaveLen(150))
+				Ω(changelog.MergeRequestsTruncated).Should(BeTrue())
+			})
+
+			Context("when there is no previous release", func() {
+				BeforeEach(func() {
+					gitlabClient.ListReleasesReturns([]*gitlab.Release{
+						buildRelease("v0.35.0", "abc123"),
+					}, nil)
+				})
+
+				It("writes an empty changelog", func() {
+					inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+					Ω(inErr).ShouldNot(HaveOccurred())
+					Ω(gitlabClient.CompareRefsCallCount()).Should(Equal(0))
+					contents, err := os.ReadFile(path.Join(destDir, "changes.md"))
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(string(contents)).Should(ContainSubstring("First release"))
 				})
 			})
 		})
 
+		Context("when repository files are requested", func() {
+			BeforeEach(func() {
+				inRequest.Params.Globs = []string{"does-not-match"}
+				gitlabClient.ListRepositoryTreeReturns([]*gitlab.TreeNode{
+					{Type: "blob", Path: "CHANGELOG.md"},
+					{Type: "tree", Path: "deploy"},
+					{Type: "blob", Path: "deploy/manifest.yml"},
+					{Type: "blob", Path: "deploy/values.yaml"},
+					{Type: "blob", Path: "deploy/logo.png"},
+				}, nil)
+			})
+
+			It("downloads literal paths without listing the tree", func() {
+				inRequest.Params.RepositoryFiles = []string{"CHANGELOG.md", "/deploy/manifest.yml"}
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				Ω(gitlabClient.ListRepositoryTreeCallCount()).Should(Equal(0))
+				Ω(gitlabClient.DownloadRepositoryFileCallCount()).Should(Equal(2))
+				_, filePath, ref, destPath := gitlabClient.DownloadRepositoryFileArgsForCall(0)
+				Ω(filePath).Should(Equal("CHANGELOG.md"))
+				Ω(ref).Should(Equal("v0.35.0"))
+				Ω(destPath).Should(Equal(path.Join(destDir, "repo", "CHANGELOG.md")))
+				_, filePath, _, destPath = gitlabClient.DownloadRepositoryFileArgsForCall(1)
+				Ω(filePath).Should(Equal("deploy/manifest.yml"))
+				Ω(destPath).Should(Equal(path.Join(destDir, "repo", "deploy", "manifest.yml")))
+				Ω(path.Join(destDir, "repo", "deploy")).Should(BeADirectory())
+			})
+
+			It("resolves globs against the tree at the release tag", func() {
+				inRequest.Params.RepositoryFiles = []string{"deploy/*.y*ml", "deploy/values.yaml"}
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				Ω(gitlabClient.ListRepositoryTreeCallCount()).Should(Equal(1))
+				_, ref := gitlabClient.ListRepositoryTreeArgsForCall(0)
+				Ω(ref).Should(Equal("v0.35.0"))
+				Ω(gitlabClient.DownloadRepositoryFileCallCount()).Should(Equal(2))
+				_, filePath, _, _ := gitlabClient.DownloadRepositoryFileArgsForCall(0)
+				Ω(filePath).Should(Equal("deploy/manifest.yml"))
+				_, filePath, _, _ = gitlabClient.DownloadRepositoryFileArgsForCall(1)
+				Ω(filePath).Should(Equal("deploy/values.yaml"))
+			})
+
+			It("keeps files inside the repo directory", func() {
+				inRequest.Params.RepositoryFiles = []string{"../../etc/passwd"}
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				_, filePath, _, destPath := gitlabClient.DownloadRepositoryFileArgsForCall(0)
+				Ω(filePath).Should(Equal("etc/passwd"))
+				Ω(destPath).Should(Equal(path.Join(destDir, "repo", "etc", "passwd")))
+			})
+
+			It("returns an error if a glob does not match any file", func() {
+				inRequest.Params.RepositoryFiles = []string{"*.gif"}
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).Should(MatchError("could not find repository file that matches glob '*.gif'"))
+			})
+		})
+
+		Context("when the release links container images", func() {
+			BeforeEach(func() {
+				release := buildRelease("v0.35.0", "abc123")
+				release.Assets.Links = append(release.Assets.Links,
+					&gitlab.ReleaseLink{ID: 4, Name: "api", URL: "registry.example.com/group/project/api:0.35.0", LinkType: gitlab.ImageLinkType},
+					&gitlab.ReleaseLink{ID: 5, Name: "worker", URL: "registry.example.com/group/project/worker@sha256:bbb", LinkType: gitlab.ImageLinkType},
+					&gitlab.ReleaseLink{ID: 6, Name: "redis", URL: "docker.io/library/redis:7", LinkType: gitlab.ImageLinkType},
+				)
+				gitlabClient.GetReleaseReturns(release, nil)
+				gitlabClient.GetProjectReturns(&gitlab.Project{ContainerRegistryImagePrefix: "registry.example.com/group/project"}, nil)
+				gitlabClient.ListRegistryRepositoriesReturns([]*gitlab.RegistryRepository{
+					{ID: 42, Location: "registry.example.com/group/project/api"},
+				}, nil)
+				gitlabClient.GetRegistryRepositoryTagReturns(&gitlab.RegistryRepositoryTag{
+					Name:   "0.35.0",
+					Digest: "sha256:aaa",
+				}, nil)
+				inRequest.Params.Globs = []string{"api", "worker", "redis"}
+			})
+
+			It("does not download them", func() {
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				Ω(gitlabClient.DownloadProjectFileCallCount()).Should(Equal(0))
+			})
+
+			It("writes their references pinned to a digest", func() {
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+
+				Ω(gitlabClient.ListRegistryRepositoriesCallCount()).Should(Equal(1))
+				_, id, tag := gitlabClient.GetRegistryRepositoryTagArgsForCall(0)
+				Ω(id).Should(Equal(int64(42)))
+				Ω(tag).Should(Equal("0.35.0"))
+
+				contents, err := os.ReadFile(path.Join(destDir, "images", "api"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(contents)).Should(Equal("registry.example.com/group/project/api:0.35.0@sha256:aaa"))
+				contents, err = os.ReadFile(path.Join(destDir, "images", "worker"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(contents)).Should(Equal("registry.example.com/group/project/worker@sha256:bbb"))
+				contents, err = os.ReadFile(path.Join(destDir, "images", "redis"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(contents)).Should(Equal("docker.io/library/redis:7"))
+			})
+
+			It("exposes them in metadata", func() {
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				Ω(inResponse.Metadata).Should(ContainElement(resource.MetadataPair{
+					Name:  "image:api",
+					Value: "registry.example.com/group/project/api:0.35.0@sha256:aaa",
+				}))
+			})
+
+			It("fails when the tag does not exist in the registry", func() {
+				gitlabClient.GetRegistryRepositoryTagReturns(nil, resource.ErrNotFound)
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).Should(MatchError("image tag 'registry.example.com/group/project/api:0.35.0' not found in container registry"))
+			})
+
+			It("does not list the project registry for external images", func() {
+				inRequest.Params.Globs = []string{"redis"}
+				gitlabClient.ListRegistryRepositoriesReturns(nil, resource.ErrForbidden)
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				Ω(gitlabClient.ListRegistryRepositoriesCallCount()).Should(Equal(0))
+
+				contents, err := os.ReadFile(path.Join(destDir, "images", "redis"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(contents)).Should(Equal("docker.io/library/redis:7"))
+			})
+
+			It("keeps the tag when the project registry cannot be read", func() {
+				gitlabClient.ListRegistryRepositoriesReturns(nil, resource.ErrForbidden)
+				output := &bytes.Buffer{}
+				command = resource.NewInCommand(gitlabClient, output)
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				Ω(output.String()).Should(ContainSubstring("cannot read the project container registry, keeping the tag of image 'registry.example.com/group/project/api:0.35.0'"))
+
+				contents, err := os.ReadFile(path.Join(destDir, "images", "api"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(contents)).Should(Equal("registry.example.com/group/project/api:0.35.0"))
+			})
+
+			It("keeps the tag when the project cannot be read", func() {
+				gitlabClient.GetProjectReturns(nil, resource.ErrNotFound)
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				Ω(gitlabClient.ListRegistryRepositoriesCallCount()).Should(Equal(0))
+
+				contents, err := os.ReadFile(path.Join(destDir, "images", "api"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(contents)).Should(Equal("registry.example.com/group/project/api:0.35.0"))
+			})
+
+			It("fails on other registry errors", func() {
+				gitlabClient.ListRegistryRepositoriesReturns(nil, errors.New("connection reset"))
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).Should(MatchError("connection reset"))
+			})
+		})
+
+		Context("when assets are renamed", func() {
+			BeforeEach(func() {
+				release := buildRelease("v0.35.0", "abc123")
+				release.Assets.Links = []*gitlab.ReleaseLink{
+					{ID: 1, Name: "tool-0.35.0-linux-amd64.tgz", URL: "tool-linux"},
+					{ID: 2, Name: "tool-0.35.0-darwin-arm64.tgz", URL: "tool-darwin"},
+					{ID: 3, Name: "README.md", URL: "readme"},
+				}
+				gitlabClient.GetReleaseReturns(release, nil)
+			})
+
+			It("writes them under their new names", func() {
+				inRequest.Params.Rename = []resource.RenameRule{
+					{From: `^tool-[0-9.]+-(.*)\.tgz$`, To: "tool-$1.tgz"},
+				}
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				Ω(gitlabClient.DownloadProjectFileCallCount()).Should(Equal(3))
+				_, _, destPath, _ := gitlabClient.DownloadProjectFileArgsForCall(0)
+				Ω(destPath).Should(Equal(path.Join(destDir, "tool-linux-amd64.tgz")))
+				_, _, destPath, _ = gitlabClient.DownloadProjectFileArgsForCall(1)
+				Ω(destPath).Should(Equal(path.Join(destDir, "tool-darwin-arm64.tgz")))
+				_, _, destPath, _ = gitlabClient.DownloadProjectFileArgsForCall(2)
+				Ω(destPath).Should(Equal(path.Join(destDir, "README.md")))
+			})
+
+			It("makes the version and tag available to templates", func() {
+				inRequest.Params.Rename = []resource.RenameRule{
+					{From: `^(.*)-0\.35\.0-(.*)$`, To: "$1-{{.Tag}}-$2"},
+				}
+				inRequest.Params.OutputDirTemplate = "assets/{{.Version}}"
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				_, _, destPath, _ := gitlabClient.DownloadProjectFileArgsForCall(0)
+				Ω(destPath).Should(Equal(path.Join(destDir, "assets", "0.35.0", "tool-v0.35.0-linux-amd64.tgz")))
+				_, _, destPath, _ = gitlabClient.DownloadProjectFileArgsForCall(2)
+				Ω(destPath).Should(Equal(path.Join(destDir, "assets", "0.35.0", "README.md")))
+				Ω(path.Join(destDir, "assets", "0.35.0")).Should(BeADirectory())
+			})
+
+			It("makes the version available to patterns", func() {
+				inRequest.Params.Rename = []resource.RenameRule{
+					{From: `^tool-{{quote .Version}}-(?P<platform>.*)\.tgz$`, To: "tool-${platform}.tgz"},
+				}
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				_, _, destPath, _ := gitlabClient.DownloadProjectFileArgsForCall(1)
+				Ω(destPath).Should(Equal(path.Join(destDir, "tool-darwin-arm64.tgz")))
+			})
+
+			It("detects conflicts before downloading", func() {
+				inRequest.Params.Rename = []resource.RenameRule{
+					{From: `^tool-.*\.tgz$`, To: "tool.tgz"},
+				}
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).Should(MatchError("assets 'tool-0.35.0-linux-amd64.tgz' and 'tool-0.35.0-darwin-arm64.tgz' are both renamed to 'tool.tgz'"))
+				Ω(gitlabClient.DownloadProjectFileCallCount()).Should(Equal(0))
+			})
+
+			It("refuses to overwrite the outputs of the resource", func() {
+				inRequest.Params.Rename = []resource.RenameRule{
+					{From: `^README\.md$`, To: "version"},
+				}
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).Should(MatchError("cannot rename asset 'README.md': 'version' is an output of the resource"))
+				Ω(gitlabClient.DownloadProjectFileCallCount()).Should(Equal(0))
+
+				inRequest.Params.Rename = nil
+				inRequest.Params.OutputDirTemplate = "images"
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).Should(MatchError(ContainSubstring("'images/")))
+			})
+
+			It("refuses to write outside of the destination directory", func() {
+				inRequest.Params.OutputDirTemplate = "../{{.Version}}"
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).Should(MatchError("path '../0.35.0' is outside of the destination directory"))
+			})
+
+			It("rejects invalid templates", func() {
+				inRequest.Params.OutputDirTemplate = "{{.Unknown}}"
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).Should(HaveOccurred())
+				Ω(gitlabClient.DownloadProjectFileCallCount()).Should(Equal(0))
+			})
+		})
+
+		Context("when size limits are given", func() {
+			BeforeEach(func() {
+				inRequest.Params.Globs = []string{"*.txt", "*.rtf"}
+				Ω(json.Unmarshal([]byte(`{"max_asset_size": "1KB", "max_total_size": 1500}`), &inRequest.Params)).Should(Succeed())
+				gitlabClient.DownloadProjectFileReturns(800, nil)
+			})
+
+			It("parses human readable sizes", func() {
+				Ω(inRequest.Params.MaxAssetSize).Should(Equal(resource.ByteSize(1000)))
+				Ω(inRequest.Params.MaxTotalSize).Should(Equal(resource.ByteSize(1500)))
+			})
+
+			It("rejects negative, infinite and overflowing sizes", func() {
+				for _, size := range []string{`-5`, `"-5MB"`, `"inf"`, `"NaN"`, `"1e400"`, `"1e18TB"`} {
+					var params resource.InParams
+					err := json.Unmarshal([]byte(`{"max_asset_size": `+size+`}`), &params)
+					Ω(err).Should(MatchError(ContainSubstring("invalid size")), size)
+				}
+			})
+
+			It("checks the announced sizes before downloading", func() {
+				gitlabClient.GetProjectFileSizeReturnsOnCall(0, 100, nil)
+				gitlabClient.GetProjectFileSizeReturnsOnCall(1, 1001, nil)
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).Should(MatchError("asset 'example.rtf' exceeds max_asset_size of 1000 bytes: 1001 bytes"))
+				Ω(gitlabClient.DownloadProjectFileCallCount()).Should(Equal(0))
+			})
+
+			It("checks the announced total size before downloading", func() {
+				gitlabClient.GetProjectFileSizeReturns(900, nil)
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).Should(MatchError("asset 'example.rtf' exceeds max_total_size of 1500 bytes: 1800 bytes in total"))
+				Ω(gitlabClient.DownloadProjectFileCallCount()).Should(Equal(0))
+			})
+
+			It("passes the remaining budget to each download", func() {
+				gitlabClient.GetProjectFileSizeReturns(-1, nil)
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				_, _, _, maxSize := gitlabClient.DownloadProjectFileArgsForCall(0)
+				Ω(maxSize).Should(Equal(int64(1000)))
+				_, _, _, maxSize = gitlabClient.DownloadProjectFileArgsForCall(1)
+				Ω(maxSize).Should(Equal(int64(700)))
+			})
+
+			It("names the asset exceeding the limits while downloading", func() {
+				gitlabClient.DownloadProjectFileReturns(0, fmt.Errorf("failed to download file `example.txt`: %w", resource.ErrSizeLimitExceeded))
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).Should(MatchError(resource.ErrSizeLimitExceeded))
+				Ω(inErr.Error()).Should(HavePrefix("asset 'example.txt' exceeds the size limits"))
+			})
+		})
+
+		Context("when the assets do not fit on disk", func() {
+			BeforeEach(func() {
+				gitlabClient.GetProjectFileSizeReturns(1<<50, nil)
+			})
+
+			It("fails before downloading", func() {
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).Should(HaveOccurred())
+				Ω(inErr.Error()).Should(HavePrefix("not enough disk space in '" + destDir + "'"))
+				Ω(gitlabClient.DownloadProjectFileCallCount()).Should(Equal(0))
+			})
+
+			It("checks the cache directory the assets are downloaded into", func() {
+				cacheDir := filepath.Join(tmpDir, "cache")
+				inRequest.Params.CacheDir = cacheDir
+
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).Should(HaveOccurred())
+				Ω(inErr.Error()).Should(HavePrefix("not enough disk space in '" + cacheDir + "'"))
+				Ω(gitlabClient.DownloadProjectFileIfModifiedCallCount()).Should(Equal(0))
+			})
+		})
+
+		Context("when a cache directory is given", func() {
+			var (
+				cacheDir string
+				contents map[string]string
+			)
+
+			BeforeEach(func() {
+				cacheDir = filepath.Join(tmpDir, "cache")
+				inRequest.Params.Globs = []string{"*.txt", "*.rtf"}
+				inRequest.Params.CacheDir = cacheDir
+				contents = map[string]string{"example.txt": "text", "example.rtf": "rich text"}
+				gitlabClient.DownloadProjectFileIfModifiedStub = func(_ context.Context, url string, destPath string, maxSize int64, validator resource.FileValidator) (resource.FileValidator, int64, error) {
+					etag := `"` + contents[url] + `"`
+					if validator.ETag == etag {
+						return validator, 0, resource.ErrNotModified
+					}
+					return resource.FileValidator{ETag: etag}, int64(len(contents[url])), os.WriteFile(destPath, []byte(contents[url]), 0644)
+				}
+			})
+
+			// cached returns the cached object of the destination file
+			cached := func(name string) os.FileInfo {
+				objects, err := filepath.Glob(filepath.Join(cacheDir, "objects", "*"))
+				Ω(err).ShouldNot(HaveOccurred())
+				dest, err := os.ReadFile(filepath.Join(destDir, name))
+				Ω(err).ShouldNot(HaveOccurred())
+				for _, object := range objects {
+					contents, err := os.ReadFile(object)
+					Ω(err).ShouldNot(HaveOccurred())
+					if bytes.Equal(contents, dest) {
+						info, err := os.Stat(object)
+						Ω(err).ShouldNot(HaveOccurred())
+						return info
+					}
+				}
+				return nil
+			}
+
+			It("copies the downloaded assets from the cache, writable", func() {
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				Ω(gitlabClient.DownloadProjectFileCallCount()).Should(Equal(0))
+				Ω(cached("example.txt")).ShouldNot(BeNil())
+				Ω(os.ReadFile(filepath.Join(destDir, "example.rtf"))).Should(Equal([]byte("rich text")))
+
+				info, err := os.Stat(filepath.Join(destDir, "example.txt"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(os.SameFile(info, cached("example.txt"))).Should(BeFalse())
+				Ω(info.Mode().Perm()).Should(Equal(os.FileMode(0644)))
+			})
+
+			It("hard links the cached assets, read-only, with cache_hard_links", func() {
+				inRequest.Params.CacheHardLinks = true
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+
+				info, err := os.Stat(filepath.Join(destDir, "example.txt"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(os.SameFile(info, cached("example.txt"))).Should(BeTrue())
+				Ω(info.Mode().Perm()).Should(Equal(os.FileMode(0444)))
+			})
+
+			It("does not use a cached copy whose checksum differs from the one of its link", func() {
+				sum := sha256.Sum256([]byte("other text"))
+				linkURL := "example.txt#sha256=" + hex.EncodeToString(sum[:])
+				release := buildRelease("v0.35.0", "abc123")
+				release.Assets.Links = []*gitlab.ReleaseLink{{ID: 1, Name: "example.txt", URL: linkURL}}
+				gitlabClient.GetReleaseReturns(release, nil)
+				contents[linkURL] = "text"
+
+				_, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				_, inErr = command.Run(context.Background(), filepath.Join(tmpDir, "other"), inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+
+				Ω(gitlabClient.DownloadProjectFileIfModifiedCallCount()).Should(Equal(2))
+				_, _, _, _, validator := gitlabClient.DownloadProjectFileIfModifiedArgsForCall(1)
+				Ω(validator).Should(Equal(resource.FileValidator{}))
+			})
+
+			It("revalidates cached assets instead of downloading them again", func() {
+				_, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				otherDir := filepath.Join(tmpDir, "other")
+				_, inErr = command.Run(context.Background(), otherDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+
+				_, _, _, _, validator := gitlabClient.DownloadProjectFileIfModifiedArgsForCall(2)
+				Ω(validator).Should(Equal(resource.FileValidator{ETag: `"text"`}))
+				Ω(os.ReadFile(filepath.Join(otherDir, "example.txt"))).Should(Equal([]byte("text")))
+			})
+
+			It("downloads assets that changed", func() {
+				_, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				contents["example.txt"] = "new text"
+				_, inErr = command.Run(context.Background(), filepath.Join(tmpDir, "other"), inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				Ω(os.ReadFile(filepath.Join(tmpDir, "other", "example.txt"))).Should(Equal([]byte("new text")))
+			})
+
+			It("uses RESOURCE_CACHE_DIR when no cache_dir is given", func() {
+				inRequest.Params.CacheDir = ""
+				os.Setenv("RESOURCE_CACHE_DIR", cacheDir)
+				defer os.Unsetenv("RESOURCE_CACHE_DIR")
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				Ω(cached("example.txt")).ShouldNot(BeNil())
+			})
+
+			It("evicts the least recently used assets beyond cache_max_size", func() {
+				inRequest.Params.CacheMaxSize = 10
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				objects, err := filepath.Glob(filepath.Join(cacheDir, "objects", "*"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(objects).Should(HaveLen(1))
+				Ω(cached("example.rtf")).ShouldNot(BeNil())
+			})
+
+			It("evicts the assets unused for longer than cache_max_age", func() {
+				_, inErr = command.Run(context.Background(), destDir, inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				entries, err := filepath.Glob(filepath.Join(cacheDir, "index", "*.json"))
+				Ω(err).ShouldNot(HaveOccurred())
+				old := time.Now().Add(-48 * time.Hour)
+				for _, entry := range entries {
+					Ω(os.Chtimes(entry, old, old)).Should(Succeed())
+				}
+
+				inRequest.Params.Globs = []string{"*.txt"}
+				Ω(json.Unmarshal([]byte(`{"cache_max_age": "24h"}`), &inRequest.Params)).Should(Succeed())
+				_, inErr = command.Run(context.Background(), filepath.Join(tmpDir, "other"), inRequest)
+				Ω(inErr).ShouldNot(HaveOccurred())
+				objects, err := filepath.Glob(filepath.Join(cacheDir, "objects", "*"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(objects).Should(HaveLen(1))
+			})
+		})
+
 		Context("when downloading an asset fails", func() {
 			BeforeEach(func() {
-				gitlabClient.DownloadProjectFileReturns(errors.New("not this time"))
-				inResponse, inErr = command.Run(destDir, inRequest)
+				gitlabClient.DownloadProjectFileReturns(0, errors.New("not this time"))
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
 			})
 
 			It("returns an error", func() {
@@ -305,7 +940,7 @@ var _ = Describe("In Command", func() {
 			inRequest.Version = &resource.Version{
 				Tag: "v0.40.0",
 			}
-			inResponse, inErr = command.Run(destDir, inRequest)
+			inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
 		})
 		It("returns an error", func() {
 			Ω(inErr).Should(MatchError("no releases"))
@@ -319,7 +954,7 @@ var _ = Describe("In Command", func() {
 			inRequest.Version = &resource.Version{
 				Tag: "some-tag",
 			}
-			inResponse, inErr = command.Run(destDir, inRequest)
+			inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
 		})
 
 		It("returns the error", func() {
@@ -330,14 +965,14 @@ var _ = Describe("In Command", func() {
 	Context("with incomplete input JSON", func() {
 		Context("is missing version", func() {
 			It("complain about it", func() {
-				inResponse, inErr = command.Run(destDir, inRequest)
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
 				Ω(inErr).Should(HaveOccurred())
 			})
 		})
 		Context("is missing version tag", func() {
 			It("complain about it", func() {
 				inRequest.Version = &resource.Version{}
-				inResponse, inErr = command.Run(destDir, inRequest)
+				inResponse, inErr = command.Run(context.Background(), destDir, inRequest)
 				Ω(inErr).Should(HaveOccurred())
 			})
 		})
@@ -0,0 +1,50 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// rollbackTimeout bounds the rollback, which also runs when the put was
+// cancelled or timed out.
+const rollbackTimeout = 2 * time.Minute
+
+// journal records how to undo each change made by a put, so that a failed put
+// leaves GitLab as it found it. Objects created by the put are deleted, while
+// objects that existed before are restored, never deleted.
+type journal struct {
+	entries []journalEntry
+}
+
+type journalEntry struct {
+	description string
+	undo        func(ctx context.Context) error
+}
+
+func (j *journal) record(description string, undo func(ctx context.Context) error) {
+	j.entries = append(j.entries, journalEntry{description: description, undo: undo})
+}
+
+// rollback undoes the recorded changes in reverse order. It carries on when a
+// step fails, and reports every step it could not undo.
+func (j *journal) rollback(ctx context.Context, writer io.Writer) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+
+	failures := []string{}
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		entry := j.entries[i]
+		fmt.Fprintf(writer, "rolling back: %s\n", entry.description)
+		if err := entry.undo(ctx); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", entry.description, err))
+		}
+	}
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "; "))
+	}
+	return nil
+}
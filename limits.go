@@ -0,0 +1,109 @@
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ByteSize is a size in bytes, given either as a number or as a string with
+// a unit such as `500MB` or `2GiB`.
+type ByteSize int64
+
+var byteSizeUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"KIB", 1 << 10},
+	{"MIB", 1 << 20},
+	{"GIB", 1 << 30},
+	{"TIB", 1 << 40},
+	{"KB", 1000},
+	{"MB", 1000 * 1000},
+	{"GB", 1000 * 1000 * 1000},
+	{"TB", 1000 * 1000 * 1000 * 1000},
+	{"K", 1 << 10},
+	{"M", 1 << 20},
+	{"G", 1 << 30},
+	{"T", 1 << 40},
+	{"B", 1},
+}
+
+func ParseByteSize(value string) (ByteSize, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	factor := int64(1)
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			factor = unit.factor
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	size := number * float64(factor)
+	if err != nil || math.IsNaN(number) || number < 0 || size >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size '%s'", value)
+	}
+	return ByteSize(size), nil
+}
+
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	var number int64
+	if err := json.Unmarshal(data, &number); err == nil {
+		if number < 0 {
+			return fmt.Errorf("invalid size %s", data)
+		}
+		*b = ByteSize(number)
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("invalid size %s", data)
+	}
+	size, err := ParseByteSize(value)
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+// Duration is a duration given as a string such as `72h` or `30m`.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return fmt.Errorf("invalid duration '%s'", value)
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// limitedReader fails with ErrSizeLimitExceeded once more than limit bytes
+// are read, unlike io.LimitReader which silently truncates. A zero limit
+// means no limit.
+type limitedReader struct {
+	reader io.Reader
+	limit  int64
+	read   int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.reader.Read(p)
+	l.read += int64(n)
+	if l.limit > 0 && l.read > l.limit {
+		return n - int(l.read-l.limit), ErrSizeLimitExceeded
+	}
+	return n, err
+}
@@ -1,11 +1,11 @@
 package resource
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 	"strings"
 
 	gitlab "gitlab.com/gitlab-org/api/client-go"
@@ -23,119 +23,107 @@ func NewOutCommand(gitlab GitLab, writer io.Writer) *OutCommand {
 	}
 }
 
-func (c *OutCommand) ensureRelease(name string, tag string, body *string) (*gitlab.Release, error) {
-	_, err := c.gitlab.GetRelease(tag)
+// ensureRelease creates the release, or updates the one that already exists,
+// and records how to undo it.
+func (c *OutCommand) ensureRelease(ctx context.Context, plan *outPlan, changes *journal) (*gitlab.Release, error) {
+	existing, err := c.gitlab.GetRelease(ctx, plan.tag)
 	if err != nil {
 		if !errors.Is(err, ErrNotFound) {
 			return nil, err
 		}
-		return c.gitlab.CreateRelease(name, tag, body)
-	}
-	return c.gitlab.UpdateRelease(name, tag, body)
-}
-
-func (c *OutCommand) ensureTag(tag string, commitishPath string) (*gitlab.Tag, error) {
-	t, err := c.gitlab.GetTag(tag)
-	if err != nil {
-		if !errors.Is(err, ErrNotFound) {
-			return nil, err
-		}
-		commitish, err := c.fileContents(commitishPath)
+		release, err := c.gitlab.CreateRelease(ctx, plan.name, plan.tag, plan.description(""))
 		if err != nil {
 			return nil, err
 		}
-		return c.gitlab.CreateTag(tag, commitish)
+		changes.record(fmt.Sprintf("deleting release '%s'", plan.tag), func(ctx context.Context) error {
+			return c.gitlab.DeleteRelease(ctx, plan.tag)
+		})
+		return release, nil
 	}
-	return t, nil
-}
 
-func (c *OutCommand) overwriteReleaseLinks(tag string, filePaths []string, req OutRequest) error {
-	links, err := c.gitlab.GetReleaseLinks(tag)
+	current := ""
+	if existing != nil {
+		current = existing.Description
+	}
+	release, err := c.gitlab.UpdateRelease(ctx, plan.name, plan.tag, plan.description(current))
 	if err != nil {
-		return err
+		return nil, err
 	}
+	changes.record(fmt.Sprintf("restoring release '%s'", plan.tag), func(ctx context.Context) error {
+		description := existing.Description
+		_, err := c.gitlab.UpdateRelease(ctx, existing.Name, plan.tag, &description)
+		return err
+	})
+	return release, nil
+}
 
-	for _, link := range links {
-		if err := c.gitlab.DeleteReleaseLink(tag, link); err != nil {
-			return err
-		}
+// ensureTag creates the tag on the commitish unless it already exists, and
+// records how to undo it.
+func (c *OutCommand) ensureTag(ctx context.Context, source Source, plan *outPlan, changes *journal) error {
+	_, err := c.gitlab.GetTag(ctx, plan.tag)
+	if err == nil {
+		return nil
 	}
-
-	for _, file := range filePaths {
-		uploadedFile, err := c.gitlab.UploadProjectFile(file)
-		if err != nil {
-			return err
-		}
-
-		url := fmt.Sprintf("%s/%s/%s", req.Source.GitLabAPIURL, req.Source.Repository, uploadedFile.URL)
-		if _, err := c.gitlab.CreateReleaseLink(tag, filepath.Base(file), url); err != nil {
-			return err
-		}
+	if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if err := checkOperations(source, opCreateTag); err != nil {
+		return fmt.Errorf("tag '%s' does not exist and %w", plan.tag, err)
+	}
+	if plan.commitish == "" {
+		return fmt.Errorf("tag '%s' does not exist and no commitish was given", plan.tag)
 	}
+	if _, err := c.gitlab.CreateTag(ctx, plan.tag, plan.commitish); err != nil {
+		return err
+	}
+	changes.record(fmt.Sprintf("deleting tag '%s'", plan.tag), func(ctx context.Context) error {
+		return c.gitlab.DeleteTag(ctx, plan.tag)
+	})
 	return nil
 }
 
-func (c *OutCommand) Run(sourceDir string, request OutRequest) (OutResponse, error) {
-	var (
-		body *string
-	)
-	params := request.Params
-
-	tag_name, err := c.fileContents(filepath.Join(sourceDir, params.TagPath))
+// Run validates every input before changing anything on GitLab, then
+// publishes the tag, the release and its assets. When a step fails, the
+// changes already made are rolled back.
+func (c *OutCommand) Run(ctx context.Context, sourceDir string, request OutRequest) (OutResponse, error) {
+	plan, err := c.preflight(sourceDir, request)
 	if err != nil {
 		return OutResponse{}, err
 	}
-	tag_name = request.Params.TagPrefix + tag_name
-
-	if params.NamePath == "" {
-		params.NamePath = params.TagPath
-	}
-	name, err := c.fileContents(filepath.Join(sourceDir, params.NamePath))
-	if err != nil {
-		return OutResponse{}, err
+	if plan.tempDir != "" {
+		defer os.RemoveAll(plan.tempDir)
 	}
 
-	if params.BodyPath != "" {
-		bodyVal, err := c.fileContents(filepath.Join(sourceDir, params.BodyPath))
-		if err != nil {
-			return OutResponse{}, err
+	changes := &journal{}
+	response, err := c.publish(ctx, request, plan, changes)
+	if err != nil {
+		if rollbackErr := changes.rollback(ctx, c.writer); rollbackErr != nil {
+			return OutResponse{}, fmt.Errorf("%w (rollback failed, clean up manually: %s)", err, rollbackErr)
 		}
-		body = &bodyVal
+		return OutResponse{}, err
 	}
+	return response, nil
+}
 
-	// ensure the tag exists, create from commitish if needed
-	_, err = c.ensureTag(tag_name, filepath.Join(sourceDir, params.CommitishPath))
-	if err != nil {
-		if err != nil {
-			return OutResponse{}, err
-		}
+func (c *OutCommand) publish(ctx context.Context, request OutRequest, plan *outPlan, changes *journal) (OutResponse, error) {
+	if err := c.ensureTag(ctx, request.Source, plan, changes); err != nil {
+		return OutResponse{}, err
 	}
 
-	// ensure release exists, create from name, tag and body if needed
-	r, err := c.ensureRelease(name, tag_name, body)
+	r, err := c.ensureRelease(ctx, plan, changes)
 	if err != nil {
 		return OutResponse{}, err
 	}
 
-	filePaths := []string{}
-	for _, glob := range params.Globs {
-		matches, err := filepath.Glob(filepath.Join(sourceDir, glob))
-		if err != nil {
-			return OutResponse{}, err
-		}
-
-		if len(matches) == 0 {
-			return OutResponse{}, fmt.Errorf("could not find file that matches glob '%s'", glob)
-		}
-		filePaths = append(filePaths, matches...)
-	}
-	if err := c.overwriteReleaseLinks(tag_name, filePaths, request); err != nil {
+	prune := request.Params.PruneAssets == nil || *request.Params.PruneAssets
+	sync, err := c.syncReleaseLinks(ctx, plan.tag, plan.store, plan.assets, prune, changes)
+	if err != nil {
 		return OutResponse{}, err
 	}
 
 	return OutResponse{
 		Version:  versionFromRelease(r),
-		Metadata: metadataFromRelease(r),
+		Metadata: append(metadataFromRelease(r), sync.metadata()...),
 	}, nil
 }
 
@@ -1,9 +1,15 @@
 package resource_test
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -35,7 +41,7 @@ var _ = Describe("Out Command", func() {
 		sourcesDir, err = os.MkdirTemp("", "gitlab-release")
 		Ω(err).ShouldNot(HaveOccurred())
 
-		gitlabClient.CreateReleaseStub = func(name string, tag string, body *string) (*gitlab.Release, error) {
+		gitlabClient.CreateReleaseStub = func(_ context.Context, name string, tag string, body *string) (*gitlab.Release, error) {
 			createdRel := gitlab.Release{}
 			createdRel.Name = name
 			if body != nil {
@@ -46,7 +52,7 @@ var _ = Describe("Out Command", func() {
 			return &createdRel, nil
 		}
 
-		gitlabClient.CreateTagStub = func(name string, ref string) (*gitlab.Tag, error) {
+		gitlabClient.CreateTagStub = func(_ context.Context, name string, ref string) (*gitlab.Tag, error) {
 			return &gitlab.Tag{
 				Commit: &gitlab.Commit{
 					ID:      ref,
@@ -55,24 +61,30 @@ var _ = Describe("Out Command", func() {
 			}, nil
 		}
 
-		gitlabClient.UpdateReleaseStub = func(name string, tag string, body *string) (*gitlab.Release, error) {
-			return gitlabClient.CreateReleaseStub(name, tag, body)
+		gitlabClient.UpdateReleaseStub = func(ctx context.Context, name string, tag string, body *string) (*gitlab.Release, error) {
+			return gitlabClient.CreateReleaseStub(ctx, name, tag, body)
 		}
 
-		gitlabClient.UploadProjectFileStub = func(file string) (*gitlab.ProjectMarkdownUploadedFile, error) {
+		gitlabClient.GetProjectReturns(&gitlab.Project{
+			ID:                42,
+			PathWithNamespace: "group/project",
+			WebURL:            "https://gitlab.example.com/group/project",
+		}, nil)
+
+		gitlabClient.UploadProjectFileStub = func(_ context.Context, file string) (*gitlab.ProjectMarkdownUploadedFile, error) {
 			return &gitlab.ProjectMarkdownUploadedFile{
 				URL: "/base/" + filepath.Base(file),
 			}, nil
 		}
 
-		gitlabClient.GetReleaseLinksStub = func(tag string) ([]*gitlab.ReleaseLink, error) {
+		gitlabClient.GetReleaseLinksStub = func(_ context.Context, tag string) ([]*gitlab.ReleaseLink, error) {
 			return []*gitlab.ReleaseLink{}, nil
 		}
 
-		gitlabClient.CreateReleaseLinkStub = func(tag string, name string, url string) (*gitlab.ReleaseLink, error) {
+		gitlabClient.CreateReleaseLinkStub = func(_ context.Context, tag string, spec resource.ReleaseLinkSpec) (*gitlab.ReleaseLink, error) {
 			return &gitlab.ReleaseLink{
-				URL:  url,
-				Name: name,
+				URL:  spec.URL,
+				Name: spec.Name,
 			}, nil
 		}
 
@@ -123,11 +135,11 @@ var _ = Describe("Out Command", func() {
 		existingReleases[1].Assets.Links = assetsLinks2
 
 		BeforeEach(func() {
-			gitlabClient.ListReleasesStub = func() ([]*gitlab.Release, error) {
+			gitlabClient.ListReleasesStub = func(_ context.Context) ([]*gitlab.Release, error) {
 				return existingReleases, nil
 			}
 
-			gitlabClient.GetReleaseStub = func(tag string) (*gitlab.Release, error) {
+			gitlabClient.GetReleaseStub = func(_ context.Context, tag string) (*gitlab.Release, error) {
 				for _, r := range existingReleases {
 					if r.TagName == tag {
 						return r, nil
@@ -136,7 +148,7 @@ var _ = Describe("Out Command", func() {
 				return nil, resource.ErrNotFound
 			}
 
-			gitlabClient.GetReleaseLinksStub = func(tag string) ([]*gitlab.ReleaseLink, error) {
+			gitlabClient.GetReleaseLinksStub = func(_ context.Context, tag string) ([]*gitlab.ReleaseLink, error) {
 				for _, r := range existingReleases {
 					if tag == r.TagName {
 						return r.Assets.Links, nil
@@ -163,12 +175,13 @@ var _ = Describe("Out Command", func() {
 		})
 
 		It("deletes the existing assets", func() {
-			_, err := command.Run(sourcesDir, request)
+			_, err := command.Run(context.Background(), sourcesDir, request)
 			Ω(err).ShouldNot(HaveOccurred())
 			Ω(gitlabClient.GetReleaseLinksCallCount()).Should(Equal(1))
-			Ω(gitlabClient.GetReleaseLinksArgsForCall(0)).Should(Equal(existingReleases[0].TagName))
+			_, tag := gitlabClient.GetReleaseLinksArgsForCall(0)
+			Ω(tag).Should(Equal(existingReleases[0].TagName))
 			Ω(gitlabClient.DeleteReleaseLinkCallCount()).Should(Equal(2))
-			arg1, arg2 := gitlabClient.DeleteReleaseLinkArgsForCall(0)
+			_, arg1, arg2 := gitlabClient.DeleteReleaseLinkArgsForCall(0)
 			Ω(arg1).Should(Equal(existingReleases[0].TagName))
 			Ω(arg2.ID).Should(Equal(assetsLinks1[0].ID))
 		})
@@ -178,10 +191,10 @@ var _ = Describe("Out Command", func() {
 				request.Params.BodyPath = ""
 			})
 			It("does not blow away the body", func() {
-				_, err := command.Run(sourcesDir, request)
+				_, err := command.Run(context.Background(), sourcesDir, request)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(gitlabClient.UpdateReleaseCallCount()).Should(Equal(1))
-				name, tag, body := gitlabClient.UpdateReleaseArgsForCall(0)
+				_, name, tag, body := gitlabClient.UpdateReleaseArgsForCall(0)
 				Ω(name).Should(Equal("v0.3.12-newname"))
 				Ω(tag).Should(Equal("v0.3.12"))
 				Ω(body).Should(BeNil())
@@ -190,10 +203,10 @@ var _ = Describe("Out Command", func() {
 
 		Context("when a commitish is not supplied", func() {
 			It("updates the existing release", func() {
-				_, err := command.Run(sourcesDir, request)
+				_, err := command.Run(context.Background(), sourcesDir, request)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(gitlabClient.UpdateReleaseCallCount()).Should(Equal(1))
-				name, tag, body := gitlabClient.UpdateReleaseArgsForCall(0)
+				_, name, tag, body := gitlabClient.UpdateReleaseArgsForCall(0)
 				Ω(tag).Should(Equal("v0.3.12"))
 				Ω(name).Should(Equal("v0.3.12-newname"))
 				Ω(*body).Should(Equal("this is a great release"))
@@ -207,16 +220,695 @@ var _ = Describe("Out Command", func() {
 				request.Params.CommitishPath = "commitish"
 			})
 			It("does not updates the existing release", func() {
-				_, err := command.Run(sourcesDir, request)
+				_, err := command.Run(context.Background(), sourcesDir, request)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(gitlabClient.CreateTagCallCount()).Should(Equal(0))
 			})
 		})
 	})
 
+	Context("when syncing assets", func() {
+		checksum := func(contents string) string {
+			sum := sha256.Sum256([]byte(contents))
+			return hex.EncodeToString(sum[:])
+		}
+
+		BeforeEach(func() {
+			gitlabClient.GetReleaseReturns(&gitlab.Release{TagName: "v0.3.13"}, nil)
+			gitlabClient.GetReleaseLinksReturns([]*gitlab.ReleaseLink{
+				{ID: 1, Name: "same.bin", URL: "https://gitlab.example.com/group/project/uploads/a/same.bin#sha256=" + checksum("same")},
+				{ID: 2, Name: "changed.bin", URL: "https://gitlab.example.com/group/project/uploads/b/changed.bin#sha256=" + checksum("before")},
+				{ID: 3, Name: "stale.bin", URL: "https://gitlab.example.com/group/project/uploads/c/stale.bin"},
+			}, nil)
+
+			file(filepath.Join(sourcesDir, "tag"), "v0.3.13")
+			file(filepath.Join(sourcesDir, "same.bin"), "same")
+			file(filepath.Join(sourcesDir, "changed.bin"), "after")
+			file(filepath.Join(sourcesDir, "new.bin"), "new")
+			request = resource.OutRequest{
+				Params: resource.OutParams{
+					TagPath: "tag",
+					Globs:   []string{"*.bin"},
+				},
+			}
+		})
+
+		It("only uploads new and changed files", func() {
+			response, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(gitlabClient.UploadProjectFileCallCount()).Should(Equal(2))
+			Ω(gitlabClient.CreateReleaseLinkCallCount()).Should(Equal(1))
+			_, _, spec := gitlabClient.CreateReleaseLinkArgsForCall(0)
+			Ω(spec.Name).Should(Equal("new.bin"))
+			Ω(spec.URL).Should(Equal("https://gitlab.example.com/group/project/base/new.bin#sha256=" + checksum("new")))
+
+			Ω(gitlabClient.UpdateReleaseLinkCallCount()).Should(Equal(1))
+			_, _, link, spec := gitlabClient.UpdateReleaseLinkArgsForCall(0)
+			Ω(link.ID).Should(Equal(int64(2)))
+			Ω(spec.URL).Should(Equal("https://gitlab.example.com/group/project/base/changed.bin#sha256=" + checksum("after")))
+
+			Ω(gitlabClient.DeleteReleaseLinkCallCount()).Should(Equal(1))
+			_, _, link = gitlabClient.DeleteReleaseLinkArgsForCall(0)
+			Ω(link.ID).Should(Equal(int64(3)))
+
+			Ω(response.Metadata).Should(ContainElements(
+				resource.MetadataPair{Name: "assets_created", Value: "1"},
+				resource.MetadataPair{Name: "assets_updated", Value: "1"},
+				resource.MetadataPair{Name: "assets_deleted", Value: "1"},
+				resource.MetadataPair{Name: "assets_unchanged", Value: "1"},
+			))
+		})
+
+		It("keeps links without files when prune_assets is false", func() {
+			prune := false
+			request.Params.PruneAssets = &prune
+
+			response, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(gitlabClient.DeleteReleaseLinkCallCount()).Should(Equal(0))
+			Ω(response.Metadata).Should(ContainElement(resource.MetadataPair{Name: "assets_deleted", Value: "0"}))
+		})
+
+		It("keeps former assets when an upload fails", func() {
+			gitlabClient.UploadProjectFileReturns(nil, errors.New("upload failed"))
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError("upload failed"))
+			Ω(gitlabClient.DeleteReleaseLinkCallCount()).Should(Equal(0))
+		})
+
+		It("refuses files published under the same name", func() {
+			Ω(os.Mkdir(filepath.Join(sourcesDir, "sub"), 0755)).Should(Succeed())
+			file(filepath.Join(sourcesDir, "sub", "new.bin"), "other")
+			request.Params.Globs = []string{"*.bin", "sub/*.bin"}
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError(fmt.Sprintf("files '%s' and '%s' would both be published as 'new.bin'", filepath.Join(sourcesDir, "new.bin"), filepath.Join(sourcesDir, "sub", "new.bin"))))
+			Ω(gitlabClient.UploadProjectFileCallCount()).Should(Equal(0))
+		})
+	})
+
+	Context("when the auth type cannot perform the operations", func() {
+		BeforeEach(func() {
+			gitlabClient.GetTagReturns(nil, resource.ErrNotFound)
+			gitlabClient.GetReleaseReturns(nil, resource.ErrNotFound)
+
+			file(filepath.Join(sourcesDir, "tag"), "v0.3.13")
+			file(filepath.Join(sourcesDir, "great-file.tgz"), "matching")
+			request = resource.OutRequest{
+				Source: resource.Source{
+					AuthType: "job_token",
+				},
+				Params: resource.OutParams{
+					TagPath: "tag",
+				},
+			}
+		})
+
+		It("fails before any change when assets must be uploaded", func() {
+			request.Params.Globs = []string{"*.tgz"}
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError("auth_type 'job_token' cannot upload assets: use a private_token or oauth token with the api scope"))
+			Ω(gitlabClient.GetTagCallCount()).Should(Equal(0))
+		})
+
+		It("fails when the tag must be created", func() {
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError("tag 'v0.3.13' does not exist and auth_type 'job_token' cannot create tags: use a private_token or oauth token with the api scope"))
+			Ω(gitlabClient.CreateTagCallCount()).Should(Equal(0))
+			Ω(gitlabClient.CreateReleaseCallCount()).Should(Equal(0))
+		})
+
+		It("lists every operation a deploy token cannot perform", func() {
+			request.Source = resource.Source{AuthType: "basic", Username: "deployer"}
+			request.Params.Globs = []string{"*.tgz"}
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError("auth_type 'basic' cannot create or update releases or upload assets: use a private_token or oauth token with the api scope"))
+		})
+	})
+
+	Context("when the access token cannot read the release", func() {
+		BeforeEach(func() {
+			gitlabClient.GetReleaseReturns(nil, &resource.RequestError{Method: "GET", StatusCode: 403})
+
+			file(filepath.Join(sourcesDir, "tag"), "v0.3.13")
+			request = resource.OutRequest{
+				Params: resource.OutParams{
+					TagPath: "tag",
+				},
+			}
+		})
+
+		It("fails without trying to create it", func() {
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError(resource.ErrForbidden))
+			Ω(gitlabClient.CreateReleaseCallCount()).Should(Equal(0))
+		})
+	})
+
+	Context("when uploading to the generic package registry", func() {
+		BeforeEach(func() {
+			gitlabClient.GetReleaseReturns(&gitlab.Release{TagName: "v1.2.0"}, nil)
+			gitlabClient.GetProjectReturns(&gitlab.Project{ID: 42, Path: "project", PathWithNamespace: "group/project"}, nil)
+			gitlabClient.PublishPackageFileStub = func(_ context.Context, packageName string, packageVersion string, file string) (string, error) {
+				return "https://gitlab.example.com/api/v4/projects/42/packages/generic/" + packageName + "/" + packageVersion + "/" + filepath.Base(file), nil
+			}
+
+			file(filepath.Join(sourcesDir, "tag"), "v1.2.0")
+			request = resource.OutRequest{
+				Params: resource.OutParams{
+					TagPath:  "tag",
+					Globs:    []string{"*.tgz"},
+					UploadTo: "generic_package",
+				},
+			}
+		})
+
+		It("links package files with permalinks", func() {
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(gitlabClient.UploadProjectFileCallCount()).Should(Equal(0))
+
+			Ω(gitlabClient.PublishPackageFileCallCount()).Should(Equal(1))
+			_, packageName, packageVersion, _ := gitlabClient.PublishPackageFileArgsForCall(0)
+			Ω(packageName).Should(Equal("project"))
+			Ω(packageVersion).Should(Equal("v1.2.0"))
+
+			_, _, spec := gitlabClient.CreateReleaseLinkArgsForCall(0)
+			Ω(spec.URL).Should(HavePrefix("https://gitlab.example.com/api/v4/projects/42/packages/generic/project/v1.2.0/great-file.tgz#sha256="))
+			Ω(spec.LinkType).Should(Equal(gitlab.PackageLinkType))
+			Ω(spec.DirectAssetPath).Should(Equal("/great-file.tgz"))
+		})
+
+		It("refuses direct asset paths colliding with the permalinks of package files", func() {
+			file(filepath.Join(sourcesDir, "other.bin"), "other")
+			request.Params.Globs = nil
+			request.Params.Assets = []resource.AssetSpec{
+				{File: "other.bin", DirectAssetPath: "/great-file.tgz"},
+				{File: "*.tgz"},
+			}
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError("assets 'other.bin' and 'great-file.tgz' would both have the direct_asset_path '/great-file.tgz'"))
+			Ω(gitlabClient.PublishPackageFileCallCount()).Should(Equal(0))
+			Ω(gitlabClient.CreateReleaseLinkCallCount()).Should(Equal(0))
+		})
+
+		It("uses package_name", func() {
+			request.Params.PackageName = "app"
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(gitlabClient.GetProjectCallCount()).Should(Equal(0))
+			_, packageName, _, _ := gitlabClient.PublishPackageFileArgsForCall(0)
+			Ω(packageName).Should(Equal("app"))
+		})
+
+		It("moves unchanged uploads to the package registry", func() {
+			sum := sha256.Sum256([]byte("matching"))
+			gitlabClient.GetReleaseLinksReturns([]*gitlab.ReleaseLink{
+				{ID: 1, Name: "great-file.tgz", URL: "https://gitlab.example.com/group/project/uploads/a/great-file.tgz#sha256=" + hex.EncodeToString(sum[:]), LinkType: gitlab.OtherLinkType},
+			}, nil)
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(gitlabClient.UpdateReleaseLinkCallCount()).Should(Equal(1))
+			_, _, _, spec := gitlabClient.UpdateReleaseLinkArgsForCall(0)
+			Ω(spec.LinkType).Should(Equal(gitlab.PackageLinkType))
+		})
+
+		It("lets job tokens publish packages with a package_name", func() {
+			request.Source.AuthType = "job_token"
+			gitlabClient.GetTagReturns(&gitlab.Tag{Name: "v1.2.0"}, nil)
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError("package_name is required with auth_type 'job_token'"))
+
+			request.Params.PackageName = "app"
+			_, err = command.Run(context.Background(), sourcesDir, request)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("rejects tags that are not valid package versions", func() {
+			file(filepath.Join(sourcesDir, "tag"), "release/1.2")
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError("tag 'release/1.2' is not a valid generic package version"))
+		})
+
+		It("rejects unknown destinations", func() {
+			request.Params.UploadTo = "ftp"
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError("invalid upload_to 'ftp': expected project_upload, generic_package or s3"))
+		})
+	})
+
+	Context("when uploading to an S3-compatible store", func() {
+		BeforeEach(func() {
+			gitlabClient.GetReleaseReturns(&gitlab.Release{TagName: "v1.2.0"}, nil)
+
+			file(filepath.Join(sourcesDir, "tag"), "v1.2.0")
+			request = resource.OutRequest{
+				Source: resource.Source{
+					S3: &resource.S3Storage{
+						Endpoint: "https://s3.example.com",
+						Bucket:   "releases",
+						Prefix:   "/group/project/",
+					},
+				},
+				Params: resource.OutParams{
+					TagPath:  "tag",
+					Globs:    []string{"*.tgz"},
+					UploadTo: "s3",
+				},
+			}
+		})
+
+		It("links the uploaded objects", func() {
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(gitlabClient.UploadProjectFileCallCount()).Should(Equal(0))
+
+			Ω(gitlabClient.UploadObjectCallCount()).Should(Equal(1))
+			_, objectURL, file := gitlabClient.UploadObjectArgsForCall(0)
+			Ω(objectURL).Should(Equal("https://s3.example.com/releases/group/project/v1.2.0/great-file.tgz"))
+			Ω(file).Should(Equal(filepath.Join(sourcesDir, "great-file.tgz")))
+
+			_, _, spec := gitlabClient.CreateReleaseLinkArgsForCall(0)
+			Ω(spec.URL).Should(HavePrefix("https://s3.example.com/releases/group/project/v1.2.0/great-file.tgz#sha256="))
+		})
+
+		It("links objects from public_url", func() {
+			request.Source.S3.PublicURL = "https://cdn.example.com/"
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).ShouldNot(HaveOccurred())
+			_, _, spec := gitlabClient.CreateReleaseLinkArgsForCall(0)
+			Ω(spec.URL).Should(HavePrefix("https://cdn.example.com/group/project/v1.2.0/great-file.tgz#sha256="))
+		})
+
+		It("lets job tokens link objects", func() {
+			request.Source.AuthType = "job_token"
+			gitlabClient.GetTagReturns(&gitlab.Tag{Name: "v1.2.0"}, nil)
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("requires the bucket", func() {
+			request.Source.S3.Bucket = ""
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError("upload_to 's3' requires the endpoint and bucket of the s3 source"))
+			Ω(gitlabClient.GetTagCallCount()).Should(Equal(0))
+		})
+	})
+
+	Context("when validating the inputs", func() {
+		BeforeEach(func() {
+			file(filepath.Join(sourcesDir, "tag"), "v0.3.13")
+			file(filepath.Join(sourcesDir, "small.bin"), "small")
+			file(filepath.Join(sourcesDir, "large.bin"), "larger than that")
+			request = resource.OutRequest{
+				Params: resource.OutParams{
+					TagPath: "tag",
+					Globs:   []string{"*.bin"},
+				},
+			}
+		})
+
+		It("enforces max_asset_size before any change", func() {
+			request.Params.MaxAssetSize = 10
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError("file 'large.bin' exceeds max_asset_size of 10 bytes: 16 bytes"))
+			Ω(gitlabClient.GetTagCallCount()).Should(Equal(0))
+		})
+
+		It("enforces max_total_size before any change", func() {
+			request.Params.MaxTotalSize = 20
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError("file 'small.bin' exceeds max_total_size of 20 bytes: 21 bytes in total"))
+			Ω(gitlabClient.GetTagCallCount()).Should(Equal(0))
+		})
+
+		It("refuses directories", func() {
+			Ω(os.Mkdir(filepath.Join(sourcesDir, "dir.bin"), 0755)).Should(Succeed())
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError(fmt.Sprintf("'%s' is not a regular file", filepath.Join(sourcesDir, "dir.bin"))))
+			Ω(gitlabClient.GetTagCallCount()).Should(Equal(0))
+		})
+
+		It("publishes files matched by several globs once", func() {
+			request.Params.Globs = []string{"*.bin", "small.*"}
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(gitlabClient.UploadProjectFileCallCount()).Should(Equal(2))
+		})
+	})
+
+	Context("when listing assets", func() {
+		BeforeEach(func() {
+			gitlabClient.GetReleaseReturns(&gitlab.Release{TagName: "v0.3.13"}, nil)
+
+			file(filepath.Join(sourcesDir, "tag"), "v0.3.13")
+			Ω(os.Mkdir(filepath.Join(sourcesDir, "dist"), 0755)).Should(Succeed())
+			file(filepath.Join(sourcesDir, "dist", "app-linux.tgz"), "linux")
+			file(filepath.Join(sourcesDir, "dist", "app-darwin.tgz"), "darwin")
+			request = resource.OutRequest{
+				Params: resource.OutParams{
+					TagPath: "tag",
+				},
+			}
+		})
+
+		It("publishes files and external URLs with their names, link types and paths", func() {
+			request.Params.Assets = []resource.AssetSpec{
+				{File: "dist/app-linux.tgz", Name: "App for Linux", LinkType: "package", DirectAssetPath: "bin/app-linux.tgz"},
+				{URL: "https://docs.example.com/runbook", Name: "Runbook", LinkType: "runbook"},
+			}
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(gitlabClient.UploadProjectFileCallCount()).Should(Equal(1))
+
+			Ω(gitlabClient.CreateReleaseLinkCallCount()).Should(Equal(2))
+			_, _, spec := gitlabClient.CreateReleaseLinkArgsForCall(0)
+			Ω(spec.Name).Should(Equal("App for Linux"))
+			Ω(spec.URL).Should(HavePrefix("https://gitlab.example.com/group/project/base/app-linux.tgz#sha256="))
+			Ω(spec.LinkType).Should(Equal(gitlab.PackageLinkType))
+			Ω(spec.DirectAssetPath).Should(Equal("/bin/app-linux.tgz"))
+
+			_, _, spec = gitlabClient.CreateReleaseLinkArgsForCall(1)
+			Ω(spec).Should(Equal(resource.ReleaseLinkSpec{Name: "Runbook", URL: "https://docs.example.com/runbook", LinkType: gitlab.RunbookLinkType}))
+		})
+
+		It("keeps external URLs that did not change", func() {
+			gitlabClient.GetReleaseLinksReturns([]*gitlab.ReleaseLink{
+				{ID: 1, Name: "docs", URL: "https://docs.example.com/docs", LinkType: gitlab.OtherLinkType},
+			}, nil)
+			request.Params.Assets = []resource.AssetSpec{{URL: "https://docs.example.com/docs"}}
+
+			response, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(gitlabClient.CreateReleaseLinkCallCount()).Should(Equal(0))
+			Ω(gitlabClient.UpdateReleaseLinkCallCount()).Should(Equal(0))
+			Ω(response.Metadata).Should(ContainElement(resource.MetadataPair{Name: "assets_unchanged", Value: "1"}))
+		})
+
+		It("reads the assets of a manifest, relative to it", func() {
+			file(filepath.Join(sourcesDir, "dist", "assets.yml"), `
+- file: "*.tgz"
+  link_type: package
+- url: https://docs.example.com/changelog.html
+  name: Changelog
+`)
+			request.Params.AssetsManifest = "dist/assets.yml"
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(gitlabClient.UploadProjectFileCallCount()).Should(Equal(2))
+			Ω(gitlabClient.CreateReleaseLinkCallCount()).Should(Equal(3))
+			names := []string{}
+			for i := 0; i < 3; i++ {
+				_, _, spec := gitlabClient.CreateReleaseLinkArgsForCall(i)
+				names = append(names, spec.Name)
+			}
+			Ω(names).Should(Equal([]string{"app-darwin.tgz", "app-linux.tgz", "Changelog"}))
+		})
+
+		It("refuses unknown fields in the manifest", func() {
+			file(filepath.Join(sourcesDir, "assets.json"), `[{"file": "dist/*.tgz", "link-type": "package"}]`)
+			request.Params.AssetsManifest = "assets.json"
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError(ContainSubstring(`unknown field "link-type"`)))
+			Ω(gitlabClient.GetTagCallCount()).Should(Equal(0))
+		})
+
+		It("refuses assets published under the same name", func() {
+			request.Params.Assets = []resource.AssetSpec{
+				{File: "dist/app-linux.tgz", Name: "app"},
+				{URL: "https://example.com/app", Name: "app"},
+			}
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError(fmt.Sprintf("assets '%s' and 'https://example.com/app' would both be published as 'app'", filepath.Join(sourcesDir, "dist", "app-linux.tgz"))))
+			Ω(gitlabClient.GetTagCallCount()).Should(Equal(0))
+		})
+
+		It("refuses a name for a glob matching several files", func() {
+			request.Params.Assets = []resource.AssetSpec{{File: "dist/*.tgz", Name: "app"}}
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError("asset 'dist/*.tgz' matches 2 files and cannot have a name or direct_asset_path"))
+		})
+
+		It("refuses unknown link types", func() {
+			request.Params.Assets = []resource.AssetSpec{{File: "dist/app-linux.tgz", LinkType: "binary"}}
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError("invalid link_type 'binary': expected other, runbook, image, package"))
+		})
+
+		It("links external URLs without the permission to upload", func() {
+			request.Source.AuthType = "job_token"
+			gitlabClient.GetTagReturns(&gitlab.Tag{Name: "v0.3.13"}, nil)
+			request.Params.Assets = []resource.AssetSpec{{URL: "https://example.com/app.tgz"}}
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).ShouldNot(HaveOccurred())
+			_, _, spec := gitlabClient.CreateReleaseLinkArgsForCall(0)
+			Ω(spec.Name).Should(Equal("app.tgz"))
+		})
+	})
+
+	Context("when generating checksums", func() {
+		var checksumsFile string
+
+		checksum := func(contents string) string {
+			sum := sha256.Sum256([]byte(contents))
+			return hex.EncodeToString(sum[:])
+		}
+
+		BeforeEach(func() {
+			gitlabClient.GetReleaseReturns(&gitlab.Release{TagName: "v0.3.13", Description: "Notes"}, nil)
+			checksumsFile = ""
+			gitlabClient.UploadProjectFileStub = func(_ context.Context, file string) (*gitlab.ProjectMarkdownUploadedFile, error) {
+				if filepath.Base(file) == "SHA256SUMS" {
+					contents, err := os.ReadFile(file)
+					Ω(err).ShouldNot(HaveOccurred())
+					checksumsFile = string(contents)
+				}
+				return &gitlab.ProjectMarkdownUploadedFile{URL: "/base/" + filepath.Base(file)}, nil
+			}
+
+			file(filepath.Join(sourcesDir, "tag"), "v0.3.13")
+			file(filepath.Join(sourcesDir, "a.bin"), "a")
+			file(filepath.Join(sourcesDir, "b.bin"), "b")
+			request = resource.OutRequest{
+				Params: resource.OutParams{
+					TagPath:           "tag",
+					Globs:             []string{"*.bin"},
+					GenerateChecksums: []string{"sha256", "sha512"},
+				},
+			}
+		})
+
+		It("publishes a checksums file per algorithm", func() {
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(checksumsFile).Should(Equal(checksum("a") + "  a.bin\n" + checksum("b") + "  b.bin\n"))
+			names := []string{}
+			for i := 0; i < gitlabClient.CreateReleaseLinkCallCount(); i++ {
+				_, _, spec := gitlabClient.CreateReleaseLinkArgsForCall(i)
+				names = append(names, spec.Name)
+			}
+			Ω(names).Should(Equal([]string{"a.bin", "b.bin", "SHA256SUMS", "SHA512SUMS"}))
+
+			_, _, _, body := gitlabClient.UpdateReleaseArgsForCall(0)
+			Ω(body).Should(BeNil())
+		})
+
+		It("appends a checksum table to the description", func() {
+			request.Params.GenerateChecksums = []string{"sha256"}
+			request.Params.ChecksumsInBody = true
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, _, _, body := gitlabClient.UpdateReleaseArgsForCall(0)
+			Ω(*body).Should(Equal("Notes\n\n<!-- generated checksums -->\n\n| Asset | SHA256 |\n| --- | --- |\n" +
+				"| a.bin | `" + checksum("a") + "` |\n| b.bin | `" + checksum("b") + "` |\n"))
+		})
+
+		It("replaces the checksum table of a former put", func() {
+			request.Params.GenerateChecksums = []string{"sha256"}
+			request.Params.ChecksumsInBody = true
+			gitlabClient.GetReleaseReturns(&gitlab.Release{TagName: "v0.3.13", Description: "Notes\n\n<!-- generated checksums -->\n\n| Asset | SHA256 |\n"}, nil)
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, _, _, body := gitlabClient.UpdateReleaseArgsForCall(0)
+			Ω(strings.Count(*body, "<!-- generated checksums -->")).Should(Equal(1))
+			Ω(*body).Should(HavePrefix("Notes\n\n<!-- generated checksums -->\n\n| Asset | SHA256 |\n| --- | --- |\n| a.bin |"))
+		})
+
+		It("refuses files published as a checksums file", func() {
+			file(filepath.Join(sourcesDir, "SHA256SUMS"), "sums")
+			request.Params.Globs = []string{"*.bin", "SHA256SUMS"}
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError(fmt.Sprintf("asset '%s' would be replaced by the SHA256SUMS generated by generate_checksums", filepath.Join(sourcesDir, "SHA256SUMS"))))
+			Ω(gitlabClient.GetTagCallCount()).Should(Equal(0))
+		})
+
+		It("refuses unknown algorithms", func() {
+			request.Params.GenerateChecksums = []string{"md5"}
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError("invalid generate_checksums algorithm 'md5': expected sha256 or sha512"))
+		})
+	})
+
+	Context("when publishing fails", func() {
+		BeforeEach(func() {
+			file(filepath.Join(sourcesDir, "tag"), "v0.3.13")
+			file(filepath.Join(sourcesDir, "commitish"), "a2f4a3")
+			file(filepath.Join(sourcesDir, "first.bin"), "first")
+			file(filepath.Join(sourcesDir, "second.bin"), "second")
+			request = resource.OutRequest{
+				Params: resource.OutParams{
+					TagPath:       "tag",
+					CommitishPath: "commitish",
+					Globs:         []string{"first.bin", "second.bin"},
+				},
+			}
+
+			gitlabClient.CreateReleaseLinkStub = func(_ context.Context, tag string, spec resource.ReleaseLinkSpec) (*gitlab.ReleaseLink, error) {
+				if spec.Name == "second.bin" {
+					return nil, errors.New("link failed")
+				}
+				return &gitlab.ReleaseLink{ID: 7, Name: spec.Name, URL: spec.URL}, nil
+			}
+		})
+
+		It("deletes the tag, the release and the links it created", func() {
+			gitlabClient.GetTagReturns(nil, resource.ErrNotFound)
+			gitlabClient.GetReleaseReturns(nil, resource.ErrNotFound)
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError("link failed"))
+
+			Ω(gitlabClient.DeleteReleaseLinkCallCount()).Should(Equal(1))
+			_, _, link := gitlabClient.DeleteReleaseLinkArgsForCall(0)
+			Ω(link.ID).Should(Equal(int64(7)))
+			Ω(gitlabClient.DeleteReleaseCallCount()).Should(Equal(1))
+			Ω(gitlabClient.DeleteTagCallCount()).Should(Equal(1))
+			_, tag := gitlabClient.DeleteTagArgsForCall(0)
+			Ω(tag).Should(Equal("v0.3.13"))
+		})
+
+		It("restores the tag, the release and the links that existed before", func() {
+			gitlabClient.GetTagReturns(&gitlab.Tag{Name: "v0.3.13"}, nil)
+			gitlabClient.GetReleaseReturns(&gitlab.Release{TagName: "v0.3.13", Name: "former", Description: "former body"}, nil)
+			former := &gitlab.ReleaseLink{ID: 3, Name: "first.bin", URL: "https://gitlab.example.com/group/project/uploads/a/first.bin"}
+			gitlabClient.GetReleaseLinksReturns([]*gitlab.ReleaseLink{former}, nil)
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError("link failed"))
+
+			Ω(gitlabClient.DeleteTagCallCount()).Should(Equal(0))
+			Ω(gitlabClient.DeleteReleaseCallCount()).Should(Equal(0))
+			Ω(gitlabClient.DeleteReleaseLinkCallCount()).Should(Equal(0))
+
+			Ω(gitlabClient.UpdateReleaseLinkCallCount()).Should(Equal(2))
+			_, _, link, spec := gitlabClient.UpdateReleaseLinkArgsForCall(1)
+			Ω(link.ID).Should(Equal(int64(3)))
+			Ω(spec.URL).Should(Equal(former.URL))
+
+			Ω(gitlabClient.UpdateReleaseCallCount()).Should(Equal(2))
+			_, name, _, body := gitlabClient.UpdateReleaseArgsForCall(1)
+			Ω(name).Should(Equal("former"))
+			Ω(*body).Should(Equal("former body"))
+		})
+
+		It("restores the direct asset path of the links it updated", func() {
+			request.Params.Globs = nil
+			request.Params.Assets = []resource.AssetSpec{
+				{File: "first.bin", DirectAssetPath: "/bin/first"},
+				{File: "second.bin"},
+			}
+			gitlabClient.GetTagReturns(&gitlab.Tag{Name: "v0.3.13"}, nil)
+			gitlabClient.GetReleaseReturns(&gitlab.Release{TagName: "v0.3.13"}, nil)
+			former := &gitlab.ReleaseLink{ID: 3, Name: "first.bin", URL: "https://gitlab.example.com/group/project/uploads/a/first.bin", LinkType: gitlab.PackageLinkType}
+			gitlabClient.GetReleaseLinksReturns([]*gitlab.ReleaseLink{former}, nil)
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError("link failed"))
+
+			Ω(gitlabClient.UpdateReleaseLinkCallCount()).Should(Equal(1))
+			_, _, _, spec := gitlabClient.UpdateReleaseLinkArgsForCall(0)
+			Ω(spec.DirectAssetPath).Should(Equal("/bin/first"))
+
+			Ω(gitlabClient.DeleteReleaseLinkCallCount()).Should(Equal(1))
+			_, _, link := gitlabClient.DeleteReleaseLinkArgsForCall(0)
+			Ω(link.ID).Should(Equal(int64(3)))
+			Ω(gitlabClient.CreateReleaseLinkCallCount()).Should(Equal(2))
+			_, _, spec = gitlabClient.CreateReleaseLinkArgsForCall(1)
+			Ω(spec).Should(Equal(resource.ReleaseLinkSpec{Name: "first.bin", URL: former.URL, LinkType: gitlab.PackageLinkType}))
+		})
+
+		It("restores the former direct asset path of the links it updated", func() {
+			request.Params.Globs = nil
+			request.Params.Assets = []resource.AssetSpec{
+				{File: "first.bin", DirectAssetPath: "/bin/first"},
+				{File: "second.bin"},
+			}
+			gitlabClient.GetTagReturns(&gitlab.Tag{Name: "v0.3.13"}, nil)
+			gitlabClient.GetReleaseReturns(&gitlab.Release{TagName: "v0.3.13"}, nil)
+			former := &gitlab.ReleaseLink{
+				ID:             3,
+				Name:           "first.bin",
+				URL:            "https://gitlab.example.com/group/project/uploads/a/first.bin",
+				DirectAssetURL: "https://gitlab.example.com/group/project/-/releases/v0.3.13/downloads/first",
+				LinkType:       gitlab.OtherLinkType,
+			}
+			gitlabClient.GetReleaseLinksReturns([]*gitlab.ReleaseLink{former}, nil)
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError("link failed"))
+
+			Ω(gitlabClient.DeleteReleaseLinkCallCount()).Should(Equal(0))
+			Ω(gitlabClient.UpdateReleaseLinkCallCount()).Should(Equal(2))
+			_, _, link, spec := gitlabClient.UpdateReleaseLinkArgsForCall(1)
+			Ω(link.ID).Should(Equal(int64(3)))
+			Ω(spec).Should(Equal(resource.ReleaseLinkSpec{Name: "first.bin", URL: former.URL, LinkType: gitlab.OtherLinkType, DirectAssetPath: "/first"}))
+		})
+
+		It("reports the changes it could not roll back", func() {
+			gitlabClient.GetTagReturns(nil, resource.ErrNotFound)
+			gitlabClient.GetReleaseReturns(nil, resource.ErrNotFound)
+			gitlabClient.DeleteReleaseReturns(errors.New("forbidden"))
+
+			_, err := command.Run(context.Background(), sourcesDir, request)
+			Ω(err).Should(MatchError("link failed (rollback failed, clean up manually: deleting release 'v0.3.13': forbidden)"))
+			Ω(gitlabClient.DeleteTagCallCount()).Should(Equal(1))
+		})
+	})
+
 	Context("when the release has not already been created", func() {
 		BeforeEach(func() {
-			gitlabClient.GetReleaseStub = func(tag string) (*gitlab.Release, error) {
+			gitlabClient.GetReleaseStub = func(_ context.Context, tag string) (*gitlab.Release, error) {
 				return nil, resource.ErrNotFound
 			}
 
@@ -237,7 +929,7 @@ var _ = Describe("Out Command", func() {
 
 		Context("when the underlying tag has not already been created", func() {
 			BeforeEach(func() {
-				gitlabClient.GetTagStub = func(name string) (*gitlab.Tag, error) {
+				gitlabClient.GetTagStub = func(_ context.Context, name string) (*gitlab.Tag, error) {
 					return nil, resource.ErrNotFound
 				}
 			})
@@ -250,28 +942,32 @@ var _ = Describe("Out Command", func() {
 				})
 
 				It("creates a release on gitlab with the tag on the commitish", func() {
-					_, err := command.Run(sourcesDir, request)
+					_, err := command.Run(context.Background(), sourcesDir, request)
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(gitlabClient.CreateTagCallCount()).Should(Equal(1))
-					tagName, ref := gitlabClient.CreateTagArgsForCall(0)
+					_, tagName, ref := gitlabClient.CreateTagArgsForCall(0)
 					Ω(tagName).Should(Equal(tagName))
 					Ω(ref).Should(Equal("a2f4a3"))
 
 					Ω(gitlabClient.CreateReleaseCallCount()).Should(Equal(1))
-					name, tag, body := gitlabClient.CreateReleaseArgsForCall(0)
+					_, name, tag, body := gitlabClient.CreateReleaseArgsForCall(0)
 					Ω(name).Should(Equal("v0.3.13"))
 					Ω(tag).Should(Equal("v0.3.13"))
 					Ω(*body).Should(Equal("*markdown*"))
 				})
 
 				It("has some sweet metadata", func() {
-					outResponse, err := command.Run(sourcesDir, request)
+					outResponse, err := command.Run(context.Background(), sourcesDir, request)
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(outResponse.Metadata).Should(ConsistOf(
 						resource.MetadataPair{Name: "tag", Value: "v0.3.13"},
 						resource.MetadataPair{Name: "name", Value: "v0.3.13"},
 						resource.MetadataPair{Name: "body", Value: "*markdown*", Markdown: true},
 						resource.MetadataPair{Name: "commit_sha", Value: "a2f4a3"},
+						resource.MetadataPair{Name: "assets_created", Value: "0"},
+						resource.MetadataPair{Name: "assets_updated", Value: "0"},
+						resource.MetadataPair{Name: "assets_deleted", Value: "0"},
+						resource.MetadataPair{Name: "assets_unchanged", Value: "0"},
 					))
 				})
 
@@ -279,7 +975,7 @@ var _ = Describe("Out Command", func() {
 
 			Context("without a commitish", func() {
 				It("fails to create the release and the tag", func() {
-					_, err := command.Run(sourcesDir, request)
+					_, err := command.Run(context.Background(), sourcesDir, request)
 					Ω(err).Should(HaveOccurred())
 					Ω(gitlabClient.CreateTagCallCount()).Should(Equal(0))
 					Ω(gitlabClient.CreateReleaseCallCount()).Should(Equal(0))
@@ -289,7 +985,7 @@ var _ = Describe("Out Command", func() {
 
 		Context("when the underlying tag has already been created", func() {
 			BeforeEach(func() {
-				gitlabClient.GetTagStub = func(name string) (*gitlab.Tag, error) {
+				gitlabClient.GetTagStub = func(_ context.Context, name string) (*gitlab.Tag, error) {
 					return &gitlab.Tag{
 						Name: "v0.3.13",
 					}, nil
@@ -297,10 +993,10 @@ var _ = Describe("Out Command", func() {
 			})
 
 			It("creates a release on gitlab with existing tag", func() {
-				_, err := command.Run(sourcesDir, request)
+				_, err := command.Run(context.Background(), sourcesDir, request)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(gitlabClient.CreateTagCallCount()).Should(Equal(0))
-				name, tag, body := gitlabClient.CreateReleaseArgsForCall(0)
+				_, name, tag, body := gitlabClient.CreateReleaseArgsForCall(0)
 				Ω(name).Should(Equal("v0.3.13"))
 				Ω(tag).Should(Equal("v0.3.13"))
 				Ω(*body).Should(Equal("*markdown*"))
@@ -321,10 +1017,10 @@ var _ = Describe("Out Command", func() {
 					}
 				})
 				It("appends the TagPrefix onto the TagName", func() {
-					_, err := command.Run(sourcesDir, request)
+					_, err := command.Run(context.Background(), sourcesDir, request)
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(gitlabClient.CreateReleaseCallCount()).Should(Equal(1))
-					name, tag, _ := gitlabClient.CreateReleaseArgsForCall(0)
+					_, name, tag, _ := gitlabClient.CreateReleaseArgsForCall(0)
 					Ω(name).Should(Equal("v0.3.13"))
 					Ω(tag).Should(Equal("v0.3.13"))
 				})
@@ -346,11 +1042,16 @@ var _ = Describe("Out Command", func() {
 			})
 
 			It("uploads matching file globs", func() {
-				_, err := command.Run(sourcesDir, request)
+				_, err := command.Run(context.Background(), sourcesDir, request)
 				Ω(err).ShouldNot(HaveOccurred())
 				Ω(gitlabClient.UploadProjectFileCallCount()).Should(Equal(1))
-				file := gitlabClient.UploadProjectFileArgsForCall(0)
+				_, file := gitlabClient.UploadProjectFileArgsForCall(0)
 				Ω(file).Should(Equal(filepath.Join(sourcesDir, "great-file.tgz")))
+
+				Ω(gitlabClient.CreateReleaseLinkCallCount()).Should(Equal(1))
+				_, _, spec := gitlabClient.CreateReleaseLinkArgsForCall(0)
+				Ω(spec.Name).Should(Equal("great-file.tgz"))
+				Ω(spec.URL).Should(HavePrefix("https://gitlab.example.com/group/project/base/great-file.tgz#sha256="))
 			})
 
 			It("returns an error if a glob is provided that does not match any files", func() {
@@ -358,9 +1059,11 @@ var _ = Describe("Out Command", func() {
 					"*.tgz",
 					"*.gif",
 				}
-				_, err := command.Run(sourcesDir, request)
+				_, err := command.Run(context.Background(), sourcesDir, request)
 				Ω(err).Should(HaveOccurred())
 				Ω(err).Should(MatchError("could not find file that matches glob '*.gif'"))
+				Ω(gitlabClient.GetTagCallCount()).Should(Equal(0))
+				Ω(gitlabClient.CreateReleaseCallCount()).Should(Equal(0))
 			})
 		})
 	})
@@ -0,0 +1,137 @@
+package resource
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// outPlan holds everything a put publishes. It is read and validated before
+// any change on GitLab, so that invalid inputs never leave a half-published
+// release behind.
+type outPlan struct {
+	tag       string
+	name      string
+	body      *string
+	commitish string
+	store     assetStore
+	assets    []releaseAsset
+	// checksum table appended to the description, if any
+	checksumTable string
+	// directory of the generated files, removed after the put
+	tempDir string
+}
+
+// preflight reads the input files, resolves the assets and enforces the size
+// limits and the permissions of the auth type. The checksums files are
+// generated last, once everything else is valid.
+func (c *OutCommand) preflight(sourceDir string, request OutRequest) (*outPlan, error) {
+	params := request.Params
+	plan := &outPlan{}
+
+	tag, err := c.fileContents(filepath.Join(sourceDir, params.TagPath))
+	if err != nil {
+		return nil, err
+	}
+	if tag == "" {
+		return nil, fmt.Errorf("tag file '%s' is empty", params.TagPath)
+	}
+	plan.tag = params.TagPrefix + tag
+
+	namePath := params.NamePath
+	if namePath == "" {
+		namePath = params.TagPath
+	}
+	if plan.name, err = c.fileContents(filepath.Join(sourceDir, namePath)); err != nil {
+		return nil, err
+	}
+
+	if params.BodyPath != "" {
+		body, err := c.fileContents(filepath.Join(sourceDir, params.BodyPath))
+		if err != nil {
+			return nil, err
+		}
+		plan.body = &body
+	}
+
+	if params.CommitishPath != "" {
+		if plan.commitish, err = c.fileContents(filepath.Join(sourceDir, params.CommitishPath)); err != nil {
+			return nil, err
+		}
+	}
+
+	if plan.store, err = newAssetStore(c.gitlab, request.Source, params, plan.tag); err != nil {
+		return nil, err
+	}
+
+	sums, err := newChecksums(params.GenerateChecksums)
+	if err != nil {
+		return nil, err
+	}
+	if params.ChecksumsInBody && len(sums.algorithms) == 0 {
+		return nil, fmt.Errorf("checksums_in_body requires generate_checksums")
+	}
+
+	specs, err := assetSpecs(sourceDir, params)
+	if err != nil {
+		return nil, err
+	}
+	if plan.assets, err = resolveAssets(sourceDir, specs, params, plan.store); err != nil {
+		return nil, err
+	}
+
+	operations := []string{opWriteRelease}
+	if operation := plan.store.operation(); operation != "" && storesFiles(plan.assets) {
+		operations = append(operations, operation)
+	}
+	if err := checkOperations(request.Source, operations...); err != nil {
+		return nil, err
+	}
+
+	if len(sums.algorithms) == 0 {
+		return plan, nil
+	}
+	if plan.tempDir, err = os.MkdirTemp("", "gitlab-release-checksums"); err != nil {
+		return nil, err
+	}
+	generated, err := sums.generate(plan.assets, plan.tempDir)
+	if err != nil {
+		os.RemoveAll(plan.tempDir)
+		return nil, err
+	}
+	plan.assets = append(plan.assets, generated...)
+	if params.ChecksumsInBody && len(generated) > 0 {
+		plan.checksumTable = sums.table()
+	}
+	return plan, nil
+}
+
+// checkAssetFile makes sure the file can be uploaded and returns its size.
+func checkAssetFile(file string) (int64, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return 0, err
+	}
+	if !info.Mode().IsRegular() {
+		return 0, fmt.Errorf("'%s' is not a regular file", file)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), f.Close()
+}
+
+// description returns the description to publish, nil to keep the current
+// one. The checksum table is appended to the body, or else to the current
+// description.
+func (p *outPlan) description(current string) *string {
+	if p.checksumTable == "" {
+		return p.body
+	}
+	if p.body != nil {
+		current = *p.body
+	}
+	description := withChecksumTable(current, p.checksumTable)
+	return &description
+}
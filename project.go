@@ -0,0 +1,89 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// normalizeRepository accepts a numeric project ID, a project path or its URL
+// encoded form such as `group%2Fproject`.
+func normalizeRepository(repository string) (string, error) {
+	unescaped, err := url.PathUnescape(repository)
+	if err != nil {
+		return "", fmt.Errorf("invalid repository '%s': %s", repository, err)
+	}
+	return strings.Trim(unescaped, "/"), nil
+}
+
+func isProjectID(repository string) bool {
+	_, err := strconv.ParseInt(repository, 10, 64)
+	return err == nil
+}
+
+// pid identifies the project in API calls: its ID once resolved, so that
+// calls keep working when the project is renamed or moved, otherwise the
+// configured repository.
+func (g *GitlabClient) pid() interface{} {
+	if g.project != nil {
+		return g.project.ID
+	}
+	return g.repository
+}
+
+// urls returns the URLs of the instance, derived from the web URL of the
+// project once resolved, which accounts for relative URL roots.
+func (g *GitlabClient) urls() *gitlabURLs {
+	if g.project != nil {
+		if urls, err := newProjectURLs(g.project); err == nil {
+			return urls
+		}
+	}
+	return g.instanceURLs
+}
+
+// GetProject resolves the repository to its project, once. GitLab resolves
+// the former path of a moved project to its current one.
+func (g *GitlabClient) GetProject(ctx context.Context) (*gitlab.Project, error) {
+	if g.project != nil {
+		return g.project, nil
+	}
+
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
+	project, resp, err := g.client.Projects.GetProject(g.repository, nil, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, apiError(resp, err)
+	}
+	g.project = project
+	return project, nil
+}
+
+// ResolveProject resolves the repository before any other call, and warns
+// when it refers to a project that has since been renamed or moved.
+func ResolveProject(ctx context.Context, client GitLab, source Source, writer io.Writer) error {
+	// job and deploy tokens cannot read projects
+	if kind, _ := authType(source); kind == authJobToken || kind == authBasic {
+		return nil
+	}
+
+	project, err := client.GetProject(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot resolve repository '%s': %w", source.Repository, err)
+	}
+
+	repository, err := normalizeRepository(source.Repository)
+	if err != nil {
+		return err
+	}
+	if !isProjectID(repository) && !strings.EqualFold(repository, project.PathWithNamespace) {
+		fmt.Fprintf(writer, "warning: repository '%s' moved to '%s', update the resource configuration\n", repository, project.PathWithNamespace)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package resource_test
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/onsi/gomega/ghttp"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+
+	resource "github.com/orange-cloudfoundry/gitlab-release-resource"
+	"github.com/orange-cloudfoundry/gitlab-release-resource/fakes"
+)
+
+var _ = Describe("GitLab Client project", func() {
+	var server *ghttp.Server
+	var source resource.Source
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		source = resource.Source{
+			Repository: "group/project",
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	newClient := func() *resource.GitlabClient {
+		source.GitLabAPIURL = server.URL()
+		client, err := resource.NewGitLabClient(source)
+		Ω(err).ShouldNot(HaveOccurred())
+		return client
+	}
+
+	It("resolves the project once and then calls it by ID", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/api/v4/projects/group/project"),
+				ghttp.RespondWith(200, `{ "id": 42, "path_with_namespace": "group/project" }`),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/api/v4/projects/42/releases/v1"),
+				ghttp.RespondWith(200, `{ "tag_name": "v1" }`),
+			),
+		)
+		client := newClient()
+
+		project, err := client.GetProject(context.Background())
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(project.ID).Should(Equal(int64(42)))
+
+		_, err = client.GetProject(context.Background())
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = client.GetRelease(context.Background(), "v1")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(server.ReceivedRequests()).Should(HaveLen(2))
+	})
+
+	It("accepts URL encoded paths", func() {
+		source.Repository = "group%2Fproject"
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/api/v4/projects/group/project/releases/v1"),
+				ghttp.RespondWith(200, `{ "tag_name": "v1" }`),
+			),
+		)
+
+		_, err := newClient().GetRelease(context.Background(), "v1")
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	It("accepts numeric project IDs", func() {
+		source.Repository = "42"
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/api/v4/projects/42"),
+				ghttp.RespondWith(200, `{ "id": 42, "path_with_namespace": "group/project" }`),
+			),
+		)
+
+		project, err := newClient().GetProject(context.Background())
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(project.PathWithNamespace).Should(Equal("group/project"))
+	})
+})
+
+var _ = Describe("ResolveProject", func() {
+	var (
+		gitlabClient *fakes.FakeGitLab
+		writer       *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		gitlabClient = &fakes.FakeGitLab{}
+		writer = &bytes.Buffer{}
+		gitlabClient.GetProjectReturns(&gitlab.Project{ID: 42, PathWithNamespace: "new-group/project"}, nil)
+	})
+
+	It("warns when the project moved", func() {
+		err := resource.ResolveProject(context.Background(), gitlabClient, resource.Source{Repository: "old-group/project"}, writer)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(writer.String()).Should(Equal("warning: repository 'old-group/project' moved to 'new-group/project', update the resource configuration\n"))
+	})
+
+	It("does not warn for the current path or an ID", func() {
+		for _, repository := range []string{"New-Group/project", "new-group%2Fproject", "42"} {
+			err := resource.ResolveProject(context.Background(), gitlabClient, resource.Source{Repository: repository}, writer)
+			Ω(err).ShouldNot(HaveOccurred())
+		}
+		Ω(writer.String()).Should(BeEmpty())
+	})
+
+	It("reports projects that cannot be resolved", func() {
+		gitlabClient.GetProjectReturns(nil, &resource.RequestError{Method: "GET", StatusCode: 404})
+		err := resource.ResolveProject(context.Background(), gitlabClient, resource.Source{Repository: "group/project"}, writer)
+		Ω(err).Should(MatchError(resource.ErrNotFound))
+		Ω(err.Error()).Should(HavePrefix("cannot resolve repository 'group/project': "))
+	})
+
+	It("skips job tokens, which cannot read projects", func() {
+		err := resource.ResolveProject(context.Background(), gitlabClient, resource.Source{Repository: "group/project", AuthType: "job_token"}, writer)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(gitlabClient.GetProjectCallCount()).Should(Equal(0))
+	})
+})
@@ -0,0 +1,128 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// templateData holds the variables available to rename and output directory
+// templates.
+type templateData struct {
+	Version string
+	Tag     string
+}
+
+var templateFuncs = template.FuncMap{
+	"quote": regexp.QuoteMeta,
+}
+
+// reservedOutputs are the files and directories written by in besides the
+// assets, which renamed assets must not overwrite.
+var reservedOutputs = map[string]bool{
+	"tag":          true,
+	"version":      true,
+	"body":         true,
+	"commit_sha":   true,
+	"changes.md":   true,
+	"changes.json": true,
+	"images":       true,
+	"repo":         true,
+}
+
+// isReservedOutput reports whether the path, relative to the destination
+// directory, is or is inside an output of in, source archives included.
+func isReservedOutput(p string) bool {
+	first := strings.SplitN(filepath.ToSlash(p), "/", 2)[0]
+	return reservedOutputs[first] || strings.HasPrefix(first, "source.")
+}
+
+type assetRenamer struct {
+	rules     []*regexp.Regexp
+	targets   []string
+	outputDir string
+}
+
+func renderTemplate(name string, text string, data templateData) (string, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func newAssetRenamer(rules []RenameRule, outputDirTemplate string, data templateData) (*assetRenamer, error) {
+	renamer := &assetRenamer{}
+	for _, rule := range rules {
+		pattern, err := renderTemplate("rename", rule.From, data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rename pattern '%s': %s", rule.From, err)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rename pattern '%s': %s", rule.From, err)
+		}
+		target, err := renderTemplate("rename", rule.To, data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rename template '%s': %s", rule.To, err)
+		}
+		renamer.rules = append(renamer.rules, re)
+		renamer.targets = append(renamer.targets, target)
+	}
+
+	if outputDirTemplate != "" {
+		outputDir, err := renderTemplate("output_dir", outputDirTemplate, data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output directory template '%s': %s", outputDirTemplate, err)
+		}
+		renamer.outputDir, err = relativePath(outputDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return renamer, nil
+}
+
+// relativePath ensures the path stays inside the destination directory.
+func relativePath(p string) (string, error) {
+	cleaned := path.Clean(filepath.ToSlash(p))
+	if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("path '%s' is outside of the destination directory", p)
+	}
+	return filepath.FromSlash(cleaned), nil
+}
+
+// rename returns the path of the asset relative to the destination directory,
+// applying the first matching rule. Assets cannot be renamed to the outputs of
+// in.
+func (r *assetRenamer) rename(name string) (string, error) {
+	original := name
+	for i, re := range r.rules {
+		if !re.MatchString(name) {
+			continue
+		}
+		name = re.ReplaceAllString(name, r.targets[i])
+		break
+	}
+
+	renamed, err := relativePath(name)
+	if err != nil {
+		return "", err
+	}
+	if renamed == "." {
+		return "", errors.New("asset renamed to an empty name")
+	}
+	renamed = filepath.Join(r.outputDir, renamed)
+	if renamed != filepath.FromSlash(original) && isReservedOutput(renamed) {
+		return "", fmt.Errorf("'%s' is an output of the resource", filepath.ToSlash(renamed))
+	}
+	return renamed, nil
+}
@@ -5,17 +5,90 @@ type Source struct {
 
 	GitLabAPIURL string `json:"gitlab_api_url"`
 	AccessToken  string `json:"access_token"`
+	AuthType     string `json:"auth_type"`
+	Username     string `json:"username"`
 	Insecure     bool   `json:"insecure"`
 
+	AccessTokenFile        string `json:"access_token_file"`
+	AccessTokenEnv         string `json:"access_token_env"`
+	TokenExpiryWarningDays *int   `json:"token_expiry_warning_days"`
+
+	CACerts       string   `json:"ca_certs"`
+	ClientCert    string   `json:"client_cert"`
+	ClientKey     string   `json:"client_key"`
+	InsecureHosts []string `json:"insecure_hosts"`
+
+	ProxyURL string   `json:"proxy_url"`
+	NoProxy  []string `json:"no_proxy"`
+
+	MaxRetries      *int     `json:"max_retries"`
+	RetryWaitMin    Duration `json:"retry_wait_min"`
+	RetryWaitMax    Duration `json:"retry_wait_max"`
+	ResponseTimeout Duration `json:"response_timeout"`
+
+	OperationTimeout Duration `json:"operation_timeout"`
+	Timeout          Duration `json:"timeout"`
+
 	TagFilter string `json:"tag_filter"`
 
 	DownloadAuths []DownloadAuth `json:"download_auths"`
+	Netrc         string         `json:"netrc"`
+
+	SignatureKeys SignatureKeys `json:"signature_keys"`
+
+	S3 *S3Storage `json:"s3"`
+}
+
+// S3Storage is an S3-compatible bucket to publish assets to with
+// `upload_to: s3`.
+type S3Storage struct {
+	Endpoint  string `json:"endpoint"`
+	Bucket    string `json:"bucket"`
+	Prefix    string `json:"prefix"`
+	PublicURL string `json:"public_url"`
+
+	S3Credentials
+}
+
+type S3Credentials struct {
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	SessionToken    string `json:"session_token"`
+	Region          string `json:"region"`
+}
+
+type SignatureKeys struct {
+	GPG      []string `json:"gpg"`
+	Minisign []string `json:"minisign"`
+	Cosign   []string `json:"cosign"`
+	SSH      []string `json:"ssh"`
 }
 
 type DownloadAuth struct {
-	Host     string `json:"host"`
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Host       string `json:"host"`
+	PathPrefix string `json:"path_prefix"`
+
+	Username string            `json:"username"`
+	Password string            `json:"password"`
+	Token    string            `json:"token"`
+	Headers  map[string]string `json:"headers"`
+
+	ClientCert string `json:"client_cert"`
+	ClientKey  string `json:"client_key"`
+
+	ProxyURL string `json:"proxy_url"`
+
+	S3 *S3Credentials `json:"s3"`
+}
+
+// AssetSpec describes a release asset: a file or glob to store, or an
+// external URL that is only linked.
+type AssetSpec struct {
+	File            string `json:"file"`
+	URL             string `json:"url"`
+	Name            string `json:"name"`
+	LinkType        string `json:"link_type"`
+	DirectAssetPath string `json:"direct_asset_path"`
 }
 
 type CheckRequest struct {
@@ -49,6 +122,28 @@ type InParams struct {
 	IncludeSources       []string `json:"include_sources"`
 	IncludeSourceTarball bool     `json:"include_source_tarball"`
 	IncludeSourceZip     bool     `json:"include_source_zip"`
+	IncludeChangelog     bool     `json:"include_changelog"`
+	RepositoryFiles      []string `json:"repository_files"`
+	SourcePath           string   `json:"source_path"`
+	SourceSHA            string   `json:"source_sha"`
+
+	Rename            []RenameRule `json:"rename"`
+	OutputDirTemplate string       `json:"output_dir_template"`
+
+	VerifySignatures bool `json:"verify_signatures"`
+
+	MaxAssetSize ByteSize `json:"max_asset_size"`
+	MaxTotalSize ByteSize `json:"max_total_size"`
+
+	CacheDir       string   `json:"cache_dir"`
+	CacheMaxSize   ByteSize `json:"cache_max_size"`
+	CacheMaxAge    Duration `json:"cache_max_age"`
+	CacheHardLinks bool     `json:"cache_hard_links"`
+}
+
+type RenameRule struct {
+	From string `json:"from"`
+	To   string `json:"to"`
 }
 
 type InResponse struct {
@@ -68,7 +163,19 @@ type OutParams struct {
 	CommitishPath string `json:"commitish"`
 	TagPrefix     string `json:"tag_prefix"`
 
-	Globs []string `json:"globs"`
+	Globs       []string `json:"globs"`
+	PruneAssets *bool    `json:"prune_assets"`
+	UploadTo    string   `json:"upload_to"`
+	PackageName string   `json:"package_name"`
+
+	Assets         []AssetSpec `json:"assets"`
+	AssetsManifest string      `json:"assets_manifest"`
+
+	MaxAssetSize ByteSize `json:"max_asset_size"`
+	MaxTotalSize ByteSize `json:"max_total_size"`
+
+	GenerateChecksums []string `json:"generate_checksums"`
+	ChecksumsInBody   bool     `json:"checksums_in_body"`
 }
 
 type OutResponse struct {
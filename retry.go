@@ -0,0 +1,168 @@
+package resource
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrRetriesExhausted = errors.New("retries exhausted")
+)
+
+const (
+	defaultMaxRetries   = 3
+	defaultRetryWaitMin = time.Second
+	defaultRetryWaitMax = 30 * time.Second
+)
+
+type retryPolicy struct {
+	maxRetries int
+	waitMin    time.Duration
+	waitMax    time.Duration
+}
+
+func newRetryPolicy(source Source) retryPolicy {
+	policy := retryPolicy{
+		maxRetries: defaultMaxRetries,
+		waitMin:    time.Duration(source.RetryWaitMin),
+		waitMax:    time.Duration(source.RetryWaitMax),
+	}
+	if source.MaxRetries != nil {
+		policy.maxRetries = *source.MaxRetries
+	}
+	if policy.waitMin == 0 {
+		policy.waitMin = defaultRetryWaitMin
+	}
+	if policy.waitMax == 0 {
+		policy.waitMax = defaultRetryWaitMax
+	}
+	if policy.waitMax < policy.waitMin {
+		policy.waitMax = policy.waitMin
+	}
+	return policy
+}
+
+// idempotent tells whether the request can be sent again after a failure
+// that may have happened once the server started processing it.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryable tells whether the attempt should be retried. Rate limited
+// requests were not processed and are retried whatever their method.
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		// an untrusted certificate will not get trusted by retrying
+		var certificateErr *tls.CertificateVerificationError
+		if errors.As(err, &certificateErr) {
+			return false
+		}
+		return idempotent(req) && req.Context().Err() == nil
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent(req)
+	}
+	return false
+}
+
+// serverWait returns the wait asked by the server through the Retry-After or
+// RateLimit-Reset headers, if any.
+func serverWait(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	if value := resp.Header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if date, err := http.ParseTime(value); err == nil {
+			return date.Sub(now), true
+		}
+	}
+	if value := resp.Header.Get("RateLimit-Reset"); value != "" {
+		if reset, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return time.Unix(reset, 0).Sub(now), true
+		}
+	}
+	return 0, false
+}
+
+// backoff returns the exponential wait before the given retry, with jitter.
+func (p retryPolicy) backoff(retry int) time.Duration {
+	wait := p.waitMin
+	for i := 1; i < retry && wait < p.waitMax; i++ {
+		wait *= 2
+	}
+	if wait > p.waitMax {
+		wait = p.waitMax
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// retryTransport retries failed requests following the policy. It is shared
+// by the API client and downloads.
+type retryTransport struct {
+	base   http.RoundTripper
+	policy retryPolicy
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempt := req
+	for retry := 0; ; retry++ {
+		resp, err := t.base.RoundTrip(attempt)
+		if !retryable(attempt, resp, err) {
+			return resp, err
+		}
+
+		failure := err
+		if failure == nil {
+			failure = newRequestError(resp, "")
+		}
+
+		wait, asked := serverWait(resp, time.Now())
+		if !asked {
+			wait = t.policy.backoff(retry + 1)
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if retry >= t.policy.maxRetries {
+			return nil, fmt.Errorf("%w: giving up on %s %s after %d attempts: %w", ErrRetriesExhausted, req.Method, redactURL(req.URL), retry+1, failure)
+		}
+		if wait > t.policy.waitMax {
+			return nil, fmt.Errorf("%w: %s %s failed with %w and the server asked to retry in %s, more than retry_wait_max", ErrRetriesExhausted, req.Method, redactURL(req.URL), failure, wait.Round(time.Second))
+		}
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return nil, fmt.Errorf("%s %s failed with %w and cannot be retried", req.Method, redactURL(req.URL), failure)
+			}
+			attempt = req.Clone(req.Context())
+			if attempt.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
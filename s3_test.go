@@ -0,0 +1,151 @@
+package resource_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/onsi/gomega/ghttp"
+
+	resource "github.com/orange-cloudfoundry/gitlab-release-resource"
+)
+
+var _ = Describe("S3 storage", func() {
+	var (
+		gitlabServer *ghttp.Server
+		s3Server     *ghttp.Server
+		s3URL        string
+		source       resource.Source
+		tmpDir       string
+	)
+
+	BeforeEach(func() {
+		gitlabServer = ghttp.NewServer()
+		s3Server = ghttp.NewServer()
+		// a host of its own, not to be taken for the GitLab host
+		s3URL = strings.Replace(s3Server.URL(), "127.0.0.1", "localhost", 1)
+
+		source = resource.Source{
+			Repository:   "group/project",
+			AccessToken:  "abc123",
+			GitLabAPIURL: gitlabServer.URL(),
+			S3: &resource.S3Storage{
+				Endpoint: s3URL,
+				Bucket:   "releases",
+				S3Credentials: resource.S3Credentials{
+					AccessKeyID:     "AKIDEXAMPLE",
+					SecretAccessKey: "secret",
+					Region:          "eu-west-1",
+				},
+			},
+		}
+
+		var err error
+		tmpDir, err = os.MkdirTemp("", "gitlab-s3")
+		Ω(err).ShouldNot(HaveOccurred())
+		file(filepath.Join(tmpDir, "asset.bin"), "asset")
+	})
+
+	AfterEach(func() {
+		gitlabServer.Close()
+		s3Server.Close()
+		os.RemoveAll(tmpDir)
+	})
+
+	newClient := func() *resource.GitlabClient {
+		client, err := resource.NewGitLabClient(source)
+		Ω(err).ShouldNot(HaveOccurred())
+		return client
+	}
+
+	verifySignature := func(payloadHash string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			Ω(r.Header.Get("Private-Token")).Should(BeEmpty())
+			Ω(r.Header.Get("X-Amz-Content-Sha256")).Should(Equal(payloadHash))
+			Ω(r.Header.Get("Authorization")).Should(MatchRegexp(`^AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/\d{8}/eu-west-1/s3/aws4_request, SignedHeaders=[a-z0-9;-]*host;x-amz-content-sha256;x-amz-date, Signature=[0-9a-f]{64}$`))
+		}
+	}
+
+	It("uploads objects with a signed payload", func() {
+		sum := sha256.Sum256([]byte("asset"))
+		s3Server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PUT", "/releases/v1.0.0/asset.bin"),
+				ghttp.VerifyBody([]byte("asset")),
+				verifySignature(hex.EncodeToString(sum[:])),
+				ghttp.RespondWith(200, nil),
+			),
+		)
+
+		err := newClient().UploadObject(context.Background(), s3URL+"/releases/v1.0.0/asset.bin", filepath.Join(tmpDir, "asset.bin"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(gitlabServer.ReceivedRequests()).Should(BeEmpty())
+	})
+
+	It("reports S3 errors", func() {
+		s3Server.AppendHandlers(
+			ghttp.RespondWith(403, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`),
+		)
+
+		err := newClient().UploadObject(context.Background(), s3URL+"/releases/v1.0.0/asset.bin", filepath.Join(tmpDir, "asset.bin"))
+		Ω(err).Should(MatchError(resource.ErrForbidden))
+		Ω(err.Error()).Should(Equal("failed to upload file `asset.bin`: HTTP status 403: AccessDenied: Access Denied"))
+	})
+
+	It("signs downloads from the bucket", func() {
+		s3Server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/releases/v1.0.0/asset.bin"),
+				verifySignature("UNSIGNED-PAYLOAD"),
+				ghttp.RespondWith(200, "asset"),
+			),
+		)
+
+		_, err := newClient().DownloadProjectFile(context.Background(), s3URL+"/releases/v1.0.0/asset.bin", filepath.Join(tmpDir, "downloaded.bin"), 0)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	It("signs downloads with the s3 credentials of download_auths", func() {
+		source.S3 = nil
+		source.DownloadAuths = []resource.DownloadAuth{{
+			Host: "localhost",
+			S3: &resource.S3Credentials{
+				AccessKeyID:     "AKIDEXAMPLE",
+				SecretAccessKey: "secret",
+				Region:          "eu-west-1",
+			},
+		}}
+		s3Server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/other-bucket/asset.bin"),
+				verifySignature("UNSIGNED-PAYLOAD"),
+				ghttp.RespondWith(200, "asset"),
+			),
+		)
+
+		_, err := newClient().DownloadProjectFile(context.Background(), s3URL+"/other-bucket/asset.bin", filepath.Join(tmpDir, "downloaded.bin"), 0)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	It("does not sign downloads outside of the bucket", func() {
+		s3Server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/public/asset.bin"),
+				func(w http.ResponseWriter, r *http.Request) {
+					Ω(r.Header.Get("Authorization")).Should(BeEmpty())
+				},
+				ghttp.RespondWith(200, "asset"),
+			),
+		)
+
+		_, err := newClient().DownloadProjectFile(context.Background(), s3URL+"/public/asset.bin", filepath.Join(tmpDir, "downloaded.bin"), 0)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+})
@@ -0,0 +1,364 @@
+package resource
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	ErrBadSignature = errors.New("bad signature")
+)
+
+// signatureSuffixes lists the companion signature asset suffixes, in lookup
+// order.
+var signatureSuffixes = []string{".asc", ".sig", ".minisig"}
+
+type signatureVerifier struct {
+	gpg      openpgp.EntityList
+	minisign []minisignPublicKey
+	cosign   []crypto.PublicKey
+	ssh      []ssh.PublicKey
+}
+
+type minisignPublicKey struct {
+	id  [8]byte
+	key ed25519.PublicKey
+}
+
+func newSignatureVerifier(keys SignatureKeys) (*signatureVerifier, error) {
+	v := &signatureVerifier{}
+
+	for _, armored := range keys.GPG {
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gpg public key: %s", err)
+		}
+		v.gpg = append(v.gpg, entities...)
+	}
+
+	for _, encoded := range keys.Minisign {
+		key, err := parseMinisignPublicKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid minisign public key: %s", err)
+		}
+		v.minisign = append(v.minisign, key)
+	}
+
+	for _, encoded := range keys.Cosign {
+		block, _ := pem.Decode([]byte(encoded))
+		if block == nil {
+			return nil, errors.New("invalid cosign public key: no PEM block found")
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cosign public key: %s", err)
+		}
+		v.cosign = append(v.cosign, key)
+	}
+
+	for _, line := range keys.SSH {
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+		if err != nil {
+			return nil, fmt.Errorf("invalid ssh public key: %s", err)
+		}
+		v.ssh = append(v.ssh, key)
+	}
+
+	if len(v.gpg) == 0 && len(v.minisign) == 0 && len(v.cosign) == 0 && len(v.ssh) == 0 {
+		return nil, errors.New("no trusted public keys configured in signature_keys")
+	}
+	return v, nil
+}
+
+// suffixes returns the companion signature suffixes that the configured keys
+// can verify, in lookup order.
+func (v *signatureVerifier) suffixes() []string {
+	suffixes := []string{}
+	if len(v.gpg) > 0 || len(v.ssh) > 0 {
+		suffixes = append(suffixes, ".asc")
+	}
+	if len(v.gpg) > 0 || len(v.cosign) > 0 || len(v.ssh) > 0 {
+		suffixes = append(suffixes, ".sig")
+	}
+	if len(v.minisign) > 0 {
+		suffixes = append(suffixes, ".minisig")
+	}
+	return suffixes
+}
+
+// isSignature tells whether the asset is itself a signature of another asset.
+func isSignature(name string) bool {
+	for _, suffix := range signatureSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// verify checks the signature of file against the trusted keys and returns a
+// description of the key that verified it.
+func (v *signatureVerifier) verify(file string, signatureFile string) (string, error) {
+	signature, err := os.ReadFile(signatureFile)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case strings.HasSuffix(signatureFile, ".minisig"):
+		return v.verifyMinisign(file, signature)
+	case bytes.HasPrefix(signature, []byte("-----BEGIN SSH SIGNATURE-----")):
+		return v.verifySSH(file, signature)
+	case bytes.HasPrefix(signature, []byte("-----BEGIN PGP SIGNATURE-----")):
+		return v.verifyGPG(file, signature, true)
+	case strings.HasSuffix(signatureFile, ".sig"):
+		// cosign signatures are base64 encoded, gpg ones are binary
+		if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature))); err == nil && len(v.cosign) > 0 {
+			return v.verifyCosign(file, decoded)
+		}
+		return v.verifyGPG(file, signature, false)
+	}
+	return "", fmt.Errorf("unsupported signature format '%s'", signatureFile)
+}
+
+func (v *signatureVerifier) verifyGPG(file string, signature []byte, armored bool) (string, error) {
+	if len(v.gpg) == 0 {
+		return "", errors.New("no trusted gpg public key configured")
+	}
+
+	reader, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	var signer *openpgp.Entity
+	if armored {
+		signer, err = openpgp.CheckArmoredDetachedSignature(v.gpg, reader, bytes.NewReader(signature), nil)
+	} else {
+		signer, err = openpgp.CheckDetachedSignature(v.gpg, reader, bytes.NewReader(signature), nil)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrBadSignature, err)
+	}
+	return fmt.Sprintf("gpg key %s", signer.PrimaryKey.KeyIdString()), nil
+}
+
+func parseMinisignPublicKey(encoded string) (minisignPublicKey, error) {
+	// accept either the public key file content or its last line only
+	lines := strings.Split(strings.TrimSpace(encoded), "\n")
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[len(lines)-1]))
+	if err != nil {
+		return minisignPublicKey{}, err
+	}
+	if len(raw) != 42 || string(raw[:2]) != "Ed" {
+		return minisignPublicKey{}, errors.New("unsupported key format")
+	}
+
+	key := minisignPublicKey{key: ed25519.PublicKey(raw[10:])}
+	copy(key.id[:], raw[2:10])
+	return key, nil
+}
+
+func (v *signatureVerifier) verifyMinisign(file string, signature []byte) (string, error) {
+	if len(v.minisign) == 0 {
+		return "", errors.New("no trusted minisign public key configured")
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(signature)), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return "", fmt.Errorf("%w: malformed minisign signature", ErrBadSignature)
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 74 {
+		return "", fmt.Errorf("%w: malformed minisign signature", ErrBadSignature)
+	}
+	globalSignature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed minisign signature", ErrBadSignature)
+	}
+	algorithm, sig := string(raw[:2]), raw[10:]
+	trustedComment := strings.TrimPrefix(strings.TrimRight(lines[2], "\r"), "trusted comment: ")
+
+	for _, key := range v.minisign {
+		if !bytes.Equal(key.id[:], raw[2:10]) {
+			continue
+		}
+
+		var message []byte
+		switch algorithm {
+		case "ED":
+			message, err = digestFile(file, func() hash.Hash {
+				h, _ := blake2b.New512(nil)
+				return h
+			})
+		case "Ed":
+			message, err = os.ReadFile(file)
+		default:
+			return "", fmt.Errorf("%w: unsupported minisign algorithm '%s'", ErrBadSignature, algorithm)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if !ed25519.Verify(key.key, message, sig) {
+			return "", ErrBadSignature
+		}
+		if !ed25519.Verify(key.key, append(append([]byte{}, sig...), trustedComment...), globalSignature) {
+			return "", fmt.Errorf("%w: invalid trusted comment signature", ErrBadSignature)
+		}
+		return fmt.Sprintf("minisign key %X", reverse(key.id[:])), nil
+	}
+	return "", fmt.Errorf("%w: signed by an unknown minisign key", ErrBadSignature)
+}
+
+func (v *signatureVerifier) verifyCosign(file string, signature []byte) (string, error) {
+	digest, err := digestFile(file, sha256.New)
+	if err != nil {
+		return "", err
+	}
+
+	for _, key := range v.cosign {
+		verified := false
+		switch k := key.(type) {
+		case *ecdsa.PublicKey:
+			verified = ecdsa.VerifyASN1(k, digest, signature)
+		case *rsa.PublicKey:
+			verified = rsa.VerifyPKCS1v15(k, crypto.SHA256, digest, signature) == nil
+		case ed25519.PublicKey:
+			message, err := os.ReadFile(file)
+			if err != nil {
+				return "", err
+			}
+			verified = ed25519.Verify(k, message, signature)
+		}
+		if verified {
+			der, _ := x509.MarshalPKIXPublicKey(key)
+			fingerprint := sha256.Sum256(der)
+			return fmt.Sprintf("cosign key %x", fingerprint[:8]), nil
+		}
+	}
+	return "", fmt.Errorf("%w: no trusted cosign key verifies the signature", ErrBadSignature)
+}
+
+// sshSignatureNamespace is the namespace used by `ssh-keygen -Y sign` for
+// files unless another one is given.
+const sshSignatureNamespace = "file"
+
+func (v *signatureVerifier) verifySSH(file string, armored []byte) (string, error) {
+	if len(v.ssh) == 0 {
+		return "", errors.New("no trusted ssh public key configured")
+	}
+
+	block, _ := pem.Decode(armored)
+	if block == nil || block.Type != "SSH SIGNATURE" {
+		return "", fmt.Errorf("%w: malformed ssh signature", ErrBadSignature)
+	}
+	data := block.Bytes
+	if len(data) < 10 || string(data[:6]) != "SSHSIG" {
+		return "", fmt.Errorf("%w: malformed ssh signature", ErrBadSignature)
+	}
+	if version := binary.BigEndian.Uint32(data[6:10]); version != 1 {
+		return "", fmt.Errorf("%w: unsupported ssh signature version %d", ErrBadSignature, version)
+	}
+	var fields struct {
+		PublicKey     []byte
+		Namespace     string
+		Reserved      []byte
+		HashAlgorithm string
+		Signature     []byte
+	}
+	if err := ssh.Unmarshal(data[10:], &fields); err != nil {
+		return "", fmt.Errorf("%w: malformed ssh signature: %s", ErrBadSignature, err)
+	}
+	if fields.Namespace != sshSignatureNamespace {
+		return "", fmt.Errorf("%w: unexpected ssh signature namespace '%s'", ErrBadSignature, fields.Namespace)
+	}
+
+	signer, err := ssh.ParsePublicKey(fields.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrBadSignature, err)
+	}
+	trusted := false
+	for _, key := range v.ssh {
+		if bytes.Equal(key.Marshal(), signer.Marshal()) {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return "", fmt.Errorf("%w: signed by an untrusted ssh key %s", ErrBadSignature, ssh.FingerprintSHA256(signer))
+	}
+
+	var newHash func() hash.Hash
+	switch fields.HashAlgorithm {
+	case "sha256":
+		newHash = sha256.New
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return "", fmt.Errorf("%w: unsupported ssh signature hash '%s'", ErrBadSignature, fields.HashAlgorithm)
+	}
+	digest, err := digestFile(file, newHash)
+	if err != nil {
+		return "", err
+	}
+
+	signed := ssh.Marshal(struct {
+		Namespace     string
+		Reserved      []byte
+		HashAlgorithm string
+		Hash          []byte
+	}{fields.Namespace, fields.Reserved, fields.HashAlgorithm, digest})
+	signature := &ssh.Signature{}
+	if err := ssh.Unmarshal(fields.Signature, signature); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrBadSignature, err)
+	}
+	if err := signer.Verify(append([]byte("SSHSIG"), signed...), signature); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrBadSignature, err)
+	}
+	return fmt.Sprintf("ssh key %s", ssh.FingerprintSHA256(signer)), nil
+}
+
+func digestFile(file string, newHash func() hash.Hash) ([]byte, error) {
+	reader, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	h := newHash()
+	if _, err := io.Copy(h, reader); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// reverse returns the bytes in reverse order, minisign displays its little
+// endian key IDs that way.
+func reverse(b []byte) []byte {
+	reversed := make([]byte, len(b))
+	for i := range b {
+		reversed[len(b)-1-i] = b[i]
+	}
+	return reversed
+}
@@ -0,0 +1,271 @@
+package resource_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"golang.org/x/crypto/blake2b"
+
+	resource "github.com/orange-cloudfoundry/gitlab-release-resource"
+	"github.com/orange-cloudfoundry/gitlab-release-resource/fakes"
+)
+
+const (
+	signedContent = "hello release\n"
+
+	gpgPublicKey = `-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+mDMEatTlQRYJKwYBBAHaRw8BAQdAN5hUBwXadoh0htzg6UKwgciFgah4qB5YO33/
+MLOIcyK0JFJlbGVhc2UgU2lnbmVyIDxyZWxlYXNlQGV4YW1wbGUuY29tPoiQBBMW
+CAA4FiEE+jKzTMN9r3m+V71UeFLYAyaCpFsFAmrU5UECGwMFCwkIBwIGFQoJCAsC
+BBYCAwECHgECF4AACgkQeFLYAyaCpFu01AEAsWb1/KhF7szoFNOnT5zZiTANDHtJ
+/Rm4JRtt9neHCo4A/RdFoDYoWhvwbnx+VF1RVnZ9m7GuHf8OaMgDkJJxyVcD
+=ba8m
+-----END PGP PUBLIC KEY BLOCK-----
+`
+	gpgSignature = `-----BEGIN PGP SIGNATURE-----
+
+iHUEABYIAB0WIQT6MrNMw32veb5XvVR4UtgDJoKkWwUCatTlQgAKCRB4UtgDJoKk
+W7rPAQDzzebXfD6lskHgtQrVzIrQWhZ163Qgh/iQv1niAIuxBgEA1mgrFWx17nN/
+RZu4ZsGCyV4OwG/+A0QPzCM6yu7ANgw=
+=vyDV
+-----END PGP SIGNATURE-----
+`
+
+	sshPublicKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIO5I7rzkJmP5EanOxexl+UQ3Pd8mHBWsQ8PVqtdLhEtf ci@example"
+	sshSignature = `-----BEGIN SSH SIGNATURE-----
+U1NIU0lHAAAAAQAAADMAAAALc3NoLWVkMjU1MTkAAAAg7kjuvOQmY/kRqc7F7GX5RDc93y
+YcFaxDw9Wq10uES18AAAAEZmlsZQAAAAAAAAAGc2hhNTEyAAAAUwAAAAtzc2gtZWQyNTUx
+OQAAAED6/CmrqsGW471KwmO8lBjg+XFrQgU0QTvqOen0x4iD9QbayP0jpxYkYQzUu6jf3o
+ZPx8tYF9bgpYrTZHAzN0EF
+-----END SSH SIGNATURE-----
+`
+)
+
+// minisign builds a public key and a prehashed signature of content, as
+// `minisign -G` and `minisign -S` would.
+func minisign(content string) (string, string) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	Ω(err).ShouldNot(HaveOccurred())
+	keyID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	key := append(append([]byte("Ed"), keyID...), public...)
+	digest := blake2b.Sum512([]byte(content))
+	sig := ed25519.Sign(private, digest[:])
+	trustedComment := "timestamp:1700000000\tfile:tool.tgz"
+	global := ed25519.Sign(private, append(append([]byte{}, sig...), trustedComment...))
+
+	publicKey := "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(key) + "\n"
+	signature := "untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte("ED"), keyID...), sig...)) + "\n" +
+		"trusted comment: " + trustedComment + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n"
+	return publicKey, signature
+}
+
+// cosign builds a public key and a signature of content, as
+// `cosign sign-blob --key` would.
+func cosign(content string) (string, string) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Ω(err).ShouldNot(HaveOccurred())
+	der, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	digest := sha256.Sum256([]byte(content))
+	sig, err := ecdsa.SignASN1(rand.Reader, private, digest[:])
+	Ω(err).ShouldNot(HaveOccurred())
+
+	publicKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	return publicKey, base64.StdEncoding.EncodeToString(sig)
+}
+
+var _ = Describe("Signature verification", func() {
+	var (
+		command      *resource.InCommand
+		gitlabClient *fakes.FakeGitLab
+		inRequest    resource.InRequest
+		tmpDir       string
+		files        map[string]string
+	)
+
+	BeforeEach(func() {
+		var err error
+		gitlabClient = &fakes.FakeGitLab{}
+		command = resource.NewInCommand(gitlabClient, io.Discard)
+		tmpDir, err = os.MkdirTemp("", "gitlab-release")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		files = map[string]string{"tool.tgz": signedContent}
+		gitlabClient.DownloadProjectFileStub = func(_ context.Context, url string, destPath string, maxSize int64) (int64, error) {
+			content, ok := files[url]
+			if !ok {
+				return 0, errors.New("unexpected download " + url)
+			}
+			return int64(len(content)), os.WriteFile(destPath, []byte(content), 0644)
+		}
+
+		inRequest = resource.InRequest{
+			Version: &resource.Version{Tag: "v1.0.0"},
+			Params: resource.InParams{
+				VerifySignatures: true,
+			},
+		}
+	})
+
+	AfterEach(func() {
+		Ω(os.RemoveAll(tmpDir)).Should(Succeed())
+	})
+
+	JustBeforeEach(func() {
+		release := &gitlab.Release{TagName: "v1.0.0"}
+		for name := range files {
+			release.Assets.Links = append(release.Assets.Links, &gitlab.ReleaseLink{Name: name, URL: name})
+		}
+		gitlabClient.GetReleaseReturns(release, nil)
+	})
+
+	run := func() (resource.InResponse, error) {
+		return command.Run(context.Background(), filepath.Join(tmpDir, "destination"), inRequest)
+	}
+
+	Context("with a gpg signature", func() {
+		BeforeEach(func() {
+			inRequest.Source.SignatureKeys.GPG = []string{gpgPublicKey}
+			files["tool.tgz.asc"] = gpgSignature
+		})
+
+		It("verifies the asset", func() {
+			response, err := run()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(response.Metadata).Should(ContainElement(resource.MetadataPair{
+				Name:  "signature:tool.tgz",
+				Value: "verified with gpg key 7852D8032682A45B",
+			}))
+		})
+
+		It("fails when the asset was tampered with", func() {
+			files["tool.tgz"] = "hello malware\n"
+			_, err := run()
+			Ω(err).Should(MatchError(resource.ErrBadSignature))
+			Ω(err.Error()).Should(HavePrefix("signature verification failed for asset 'tool.tgz'"))
+		})
+	})
+
+	Context("with an ssh signature", func() {
+		BeforeEach(func() {
+			inRequest.Source.SignatureKeys.SSH = []string{sshPublicKey}
+			files["tool.tgz.sig"] = sshSignature
+		})
+
+		It("verifies the asset", func() {
+			response, err := run()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(response.Metadata).Should(ContainElement(resource.MetadataPair{
+				Name:  "signature:tool.tgz",
+				Value: "verified with ssh key SHA256:rHFEai09RflaiVA3U300T8/n7bKFrZHryU1pn2FAjyw",
+			}))
+		})
+
+		It("fails when the key is not trusted", func() {
+			inRequest.Source.SignatureKeys.SSH = []string{"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBvG8tWnR3KHfL5H+bVvZ4L7Z0kVdc6G3m0xkXf0Vw2P other@example"}
+			_, err := run()
+			Ω(err).Should(MatchError(resource.ErrBadSignature))
+		})
+	})
+
+	Context("with a minisign signature", func() {
+		BeforeEach(func() {
+			publicKey, signature := minisign(signedContent)
+			inRequest.Source.SignatureKeys.Minisign = []string{publicKey}
+			files["tool.tgz.minisig"] = signature
+		})
+
+		It("verifies the asset", func() {
+			response, err := run()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(response.Metadata).Should(ContainElement(resource.MetadataPair{
+				Name:  "signature:tool.tgz",
+				Value: "verified with minisign key 0807060504030201",
+			}))
+		})
+
+		It("fails when the asset was tampered with", func() {
+			files["tool.tgz"] = "hello malware\n"
+			_, err := run()
+			Ω(err).Should(MatchError(resource.ErrBadSignature))
+		})
+	})
+
+	Context("with a cosign signature", func() {
+		BeforeEach(func() {
+			publicKey, signature := cosign(signedContent)
+			inRequest.Source.SignatureKeys.Cosign = []string{publicKey}
+			files["tool.tgz.sig"] = signature
+		})
+
+		It("verifies the asset", func() {
+			response, err := run()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(response.Metadata).Should(ContainElement(HaveField("Name", "signature:tool.tgz")))
+		})
+
+		It("fails when signed by another key", func() {
+			publicKey, _ := cosign(signedContent)
+			inRequest.Source.SignatureKeys.Cosign = []string{publicKey}
+			_, err := run()
+			Ω(err).Should(MatchError(resource.ErrBadSignature))
+		})
+	})
+
+	Context("with signatures of several types", func() {
+		BeforeEach(func() {
+			publicKey, signature := minisign(signedContent)
+			inRequest.Source.SignatureKeys.Minisign = []string{publicKey}
+			files["tool.tgz.asc"] = gpgSignature
+			files["tool.tgz.minisig"] = signature
+		})
+
+		It("verifies the one a key is configured for", func() {
+			response, err := run()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(response.Metadata).Should(ContainElement(resource.MetadataPair{
+				Name:  "signature:tool.tgz",
+				Value: "verified with minisign key 0807060504030201",
+			}))
+		})
+	})
+
+	Context("when the signature is missing", func() {
+		BeforeEach(func() {
+			inRequest.Source.SignatureKeys.GPG = []string{gpgPublicKey}
+		})
+
+		It("fails the get", func() {
+			_, err := run()
+			Ω(err).Should(MatchError("signature verification failed for asset 'tool.tgz': no signature found, expected one of .asc, .sig"))
+		})
+	})
+
+	Context("when no trusted key is configured", func() {
+		It("fails before downloading", func() {
+			_, err := run()
+			Ω(err).Should(MatchError("no trusted public keys configured in signature_keys"))
+			Ω(gitlabClient.DownloadProjectFileCallCount()).Should(Equal(0))
+		})
+	})
+})
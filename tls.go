@@ -0,0 +1,172 @@
+package resource
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// matchHost tells whether host matches the pattern: an exact host name, a
+// wildcard such as `*.example.com` matching its subdomains, or `*`.
+func matchHost(pattern string, host string) bool {
+	pattern, host = strings.ToLower(pattern), strings.ToLower(host)
+	switch {
+	case pattern == "*":
+		return true
+	case strings.HasPrefix(pattern, "*."):
+		return strings.HasSuffix(host, pattern[1:])
+	}
+	return pattern == host
+}
+
+// parseClientCertificate loads a PEM encoded certificate and key, if any.
+func parseClientCertificate(cert string, key string) (*tls.Certificate, error) {
+	if cert == "" && key == "" {
+		return nil, nil
+	}
+	certificate, err := tls.X509KeyPair([]byte(cert), []byte(key))
+	if err != nil {
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+// newRootCAs returns the system certificate pool extended with the given PEM
+// encoded certificates.
+func newRootCAs(caCerts string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if caCerts != "" && !pool.AppendCertsFromPEM([]byte(caCerts)) {
+		return nil, errors.New("invalid ca_certs: no PEM certificate found")
+	}
+	return pool, nil
+}
+
+// transports hands out HTTP transports sharing the trusted CAs and proxy
+// settings, one for each client certificate, verification mode and proxy so
+// that connections are reused.
+type transports struct {
+	rootCAs         *x509.CertPool
+	insecureHosts   []string
+	proxy           *url.URL
+	noProxy         []string
+	retryPolicy     retryPolicy
+	responseTimeout time.Duration
+
+	mu    sync.Mutex
+	cache map[transportKey]http.RoundTripper
+}
+
+type transportKey struct {
+	certificate *tls.Certificate
+	insecure    bool
+	proxy       string
+}
+
+// directProxy disables the proxy for a host listed in no_proxy.
+const directProxy = "direct"
+
+func newTransports(source Source) (*transports, error) {
+	rootCAs, err := newRootCAs(source.CACerts)
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := parseProxyURL("proxy_url", source.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+	return &transports{
+		rootCAs:         rootCAs,
+		insecureHosts:   source.InsecureHosts,
+		proxy:           proxy,
+		noProxy:         source.NoProxy,
+		retryPolicy:     newRetryPolicy(source),
+		responseTimeout: time.Duration(source.ResponseTimeout),
+		cache:           map[transportKey]http.RoundTripper{},
+	}, nil
+}
+
+// parseProxyURL parses an optional proxy URL. Errors never include the URL,
+// which may contain credentials.
+func parseProxyURL(name string, value string) (*url.URL, error) {
+	if value == "" {
+		return nil, nil
+	}
+	proxy, err := url.Parse(value)
+	if err != nil || proxy.Host == "" {
+		return nil, fmt.Errorf("invalid %s: expected an URL such as http://proxy.example.com:3128", name)
+	}
+	return proxy, nil
+}
+
+// proxyFor returns the proxy to use for host: the override of its
+// download_auths entry, otherwise proxy_url unless the host is listed in
+// no_proxy. Without proxy_url, the environment variables apply.
+func (t *transports) proxyFor(host string, override *url.URL) string {
+	switch {
+	case override != nil:
+		return override.String()
+	case t.proxy == nil:
+		return ""
+	}
+	for _, pattern := range t.noProxy {
+		if matchHost(pattern, host) {
+			return directProxy
+		}
+	}
+	return t.proxy.String()
+}
+
+func (t *transports) insecure(host string) bool {
+	for _, pattern := range t.insecureHosts {
+		if matchHost(pattern, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// get returns the retrying transport for host, presenting certificate when
+// the server asks for one.
+func (t *transports) get(host string, certificate *tls.Certificate, proxy *url.URL) http.RoundTripper {
+	key := transportKey{
+		certificate: certificate,
+		insecure:    t.insecure(host),
+		proxy:       t.proxyFor(host, proxy),
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if transport, ok := t.cache[key]; ok {
+		return transport
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		RootCAs:            t.rootCAs,
+		InsecureSkipVerify: key.insecure,
+	}
+	transport.ResponseHeaderTimeout = t.responseTimeout
+	if certificate != nil {
+		transport.TLSClientConfig.Certificates = []tls.Certificate{*certificate}
+	}
+	switch key.proxy {
+	case "":
+		transport.Proxy = http.ProxyFromEnvironment
+	case directProxy:
+		transport.Proxy = nil
+	default:
+		proxy, _ := url.Parse(key.proxy)
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	t.cache[key] = &retryTransport{base: transport, policy: t.retryPolicy}
+	return t.cache[key]
+}
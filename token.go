@@ -0,0 +1,121 @@
+package resource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const defaultTokenExpiryWarningDays = 14
+
+// resolveAccessToken returns the token given inline, read from
+// access_token_file or from the access_token_env environment variable.
+func resolveAccessToken(source Source) (string, error) {
+	set := 0
+	for _, value := range []string{source.AccessToken, source.AccessTokenFile, source.AccessTokenEnv} {
+		if value != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return "", errors.New("only one of access_token, access_token_file and access_token_env can be set")
+	}
+
+	switch {
+	case source.AccessTokenFile != "":
+		content, err := os.ReadFile(source.AccessTokenFile)
+		if err != nil {
+			return "", fmt.Errorf("cannot read access_token_file: %s", err)
+		}
+		token := strings.TrimSpace(string(content))
+		if token == "" {
+			return "", fmt.Errorf("access_token_file '%s' is empty", source.AccessTokenFile)
+		}
+		return token, nil
+	case source.AccessTokenEnv != "":
+		token := strings.TrimSpace(os.Getenv(source.AccessTokenEnv))
+		if token == "" {
+			return "", fmt.Errorf("environment variable '%s' of access_token_env is not set", source.AccessTokenEnv)
+		}
+		return token, nil
+	}
+	return source.AccessToken, nil
+}
+
+// hasScope tells whether scopes grant scope, `api` granting `read_api` too.
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope || (s == "api" && scope == "read_api") {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckAccessToken introspects the access token to fail early when it is
+// expired or lacks scope, and warns when it expires soon. Tokens that cannot
+// be introspected, such as job or OAuth tokens, are not checked.
+func CheckAccessToken(ctx context.Context, client GitLab, source Source, writer io.Writer, scope string) error {
+	token, err := client.GetAccessToken(ctx)
+	if err != nil {
+		if errors.Is(err, ErrUnauthorized) {
+			return fmt.Errorf("access token is invalid, expired or revoked: %w", err)
+		}
+		// e.g. GitLab before 15.5 has no introspection endpoint
+		fmt.Fprintf(writer, "cannot check the access token: %s\n", err)
+		return nil
+	}
+	if token == nil {
+		return nil
+	}
+
+	if token.Revoked {
+		return fmt.Errorf("access token '%s' is revoked", token.Name)
+	}
+	if token.ExpiresAt != nil {
+		expiresAt := time.Time(*token.ExpiresAt)
+		remaining := time.Until(expiresAt)
+		if remaining < 0 {
+			return fmt.Errorf("access token '%s' expired on %s", token.Name, expiresAt.Format(time.DateOnly))
+		}
+		warning := defaultTokenExpiryWarningDays
+		if source.TokenExpiryWarningDays != nil {
+			warning = *source.TokenExpiryWarningDays
+		}
+		if days := int(math.Ceil(remaining.Hours() / 24)); days <= warning {
+			fmt.Fprintf(writer, "warning: access token '%s' expires on %s, in %d day(s)\n", token.Name, expiresAt.Format(time.DateOnly), days)
+		}
+	}
+	if !token.Active {
+		return fmt.Errorf("access token '%s' is inactive", token.Name)
+	}
+
+	if !hasScope(token.Scopes, scope) {
+		return fmt.Errorf("access token '%s' lacks the %s scope, it has: %s", token.Name, scope, strings.Join(token.Scopes, ", "))
+	}
+	return nil
+}
+
+// GetAccessToken returns the introspection of the access token, or nil when
+// the auth type has no introspection.
+func (g *GitlabClient) GetAccessToken(ctx context.Context) (*gitlab.PersonalAccessToken, error) {
+	if g.authType != authPrivateToken || g.accessToken == "" {
+		return nil, nil
+	}
+
+	ctx, cancel := g.withTimeout(ctx)
+	defer cancel()
+
+	token, resp, err := g.client.PersonalAccessTokens.GetSinglePersonalAccessToken(gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, apiError(resp, err)
+	}
+	return token, nil
+}
@@ -0,0 +1,177 @@
+package resource_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/onsi/gomega/ghttp"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+
+	resource "github.com/orange-cloudfoundry/gitlab-release-resource"
+	"github.com/orange-cloudfoundry/gitlab-release-resource/fakes"
+)
+
+var _ = Describe("Access token sources", func() {
+	var server *ghttp.Server
+	var tmpDir string
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/api/v4/projects/concourse/repository/tags"),
+				ghttp.VerifyHeaderKV("Private-Token", "abc123"),
+				ghttp.RespondWith(200, "[]"),
+			),
+		)
+
+		var err error
+		tmpDir, err = os.MkdirTemp("", "gitlab-token")
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(tmpDir)
+	})
+
+	It("reads the token from access_token_file", func() {
+		tokenFile := filepath.Join(tmpDir, "token")
+		file(tokenFile, "abc123\n")
+
+		client, err := resource.NewGitLabClient(resource.Source{
+			Repository:      "concourse",
+			GitLabAPIURL:    server.URL(),
+			AccessTokenFile: tokenFile,
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		_, err = client.ListTags(context.Background())
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	It("reads the token from access_token_env", func() {
+		os.Setenv("GITLAB_RELEASE_TEST_TOKEN", "abc123")
+		defer os.Unsetenv("GITLAB_RELEASE_TEST_TOKEN")
+
+		client, err := resource.NewGitLabClient(resource.Source{
+			Repository:     "concourse",
+			GitLabAPIURL:   server.URL(),
+			AccessTokenEnv: "GITLAB_RELEASE_TEST_TOKEN",
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		_, err = client.ListTags(context.Background())
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	It("fails when the environment variable is not set", func() {
+		_, err := resource.NewGitLabClient(resource.Source{AccessTokenEnv: "GITLAB_RELEASE_TEST_UNSET"})
+		Ω(err).Should(MatchError("environment variable 'GITLAB_RELEASE_TEST_UNSET' of access_token_env is not set"))
+	})
+
+	It("fails when the file is empty", func() {
+		tokenFile := filepath.Join(tmpDir, "token")
+		file(tokenFile, "\n")
+
+		_, err := resource.NewGitLabClient(resource.Source{AccessTokenFile: tokenFile})
+		Ω(err).Should(MatchError("access_token_file '" + tokenFile + "' is empty"))
+	})
+
+	It("refuses several token sources", func() {
+		_, err := resource.NewGitLabClient(resource.Source{AccessToken: "abc123", AccessTokenEnv: "GITLAB_TOKEN"})
+		Ω(err).Should(MatchError("only one of access_token, access_token_file and access_token_env can be set"))
+	})
+})
+
+var _ = Describe("CheckAccessToken", func() {
+	var (
+		gitlabClient *fakes.FakeGitLab
+		source       resource.Source
+		writer       *bytes.Buffer
+		token        *gitlab.PersonalAccessToken
+	)
+
+	expiresIn := func(days int) *gitlab.ISOTime {
+		expiresAt := gitlab.ISOTime(time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, days))
+		return &expiresAt
+	}
+
+	BeforeEach(func() {
+		gitlabClient = &fakes.FakeGitLab{}
+		source = resource.Source{}
+		writer = &bytes.Buffer{}
+		token = &gitlab.PersonalAccessToken{
+			Name:      "release",
+			Active:    true,
+			Scopes:    []string{"api"},
+			ExpiresAt: expiresIn(90),
+		}
+		gitlabClient.GetAccessTokenReturns(token, nil)
+	})
+
+	It("accepts a valid token", func() {
+		Ω(resource.CheckAccessToken(context.Background(), gitlabClient, source, writer, "api")).Should(Succeed())
+		Ω(writer.String()).Should(BeEmpty())
+	})
+
+	It("skips tokens without introspection", func() {
+		gitlabClient.GetAccessTokenReturns(nil, nil)
+		Ω(resource.CheckAccessToken(context.Background(), gitlabClient, source, writer, "api")).Should(Succeed())
+	})
+
+	It("warns when the token expires soon", func() {
+		token.ExpiresAt = expiresIn(3)
+		Ω(resource.CheckAccessToken(context.Background(), gitlabClient, source, writer, "api")).Should(Succeed())
+		Ω(writer.String()).Should(ContainSubstring("warning: access token 'release' expires on "))
+	})
+
+	It("honours token_expiry_warning_days", func() {
+		days := 1
+		source.TokenExpiryWarningDays = &days
+		token.ExpiresAt = expiresIn(3)
+		Ω(resource.CheckAccessToken(context.Background(), gitlabClient, source, writer, "api")).Should(Succeed())
+		Ω(writer.String()).Should(BeEmpty())
+	})
+
+	It("fails when the token expired", func() {
+		token.Active = false
+		token.ExpiresAt = expiresIn(-2)
+		err := resource.CheckAccessToken(context.Background(), gitlabClient, source, writer, "api")
+		Ω(err).Should(MatchError("access token 'release' expired on " + time.Time(*token.ExpiresAt).Format(time.DateOnly)))
+	})
+
+	It("fails when the token is inactive before its expiry", func() {
+		token.Active = false
+		err := resource.CheckAccessToken(context.Background(), gitlabClient, source, writer, "api")
+		Ω(err).Should(MatchError("access token 'release' is inactive"))
+		Ω(writer.String()).Should(BeEmpty())
+	})
+
+	It("fails when the token is inactive and never expires", func() {
+		token.Active = false
+		token.ExpiresAt = nil
+		err := resource.CheckAccessToken(context.Background(), gitlabClient, source, writer, "api")
+		Ω(err).Should(MatchError("access token 'release' is inactive"))
+	})
+
+	It("fails when the token lacks the scope", func() {
+		token.Scopes = []string{"read_api", "read_repository"}
+		err := resource.CheckAccessToken(context.Background(), gitlabClient, source, writer, "api")
+		Ω(err).Should(MatchError("access token 'release' lacks the api scope, it has: read_api, read_repository"))
+	})
+
+	It("accepts api for read_api", func() {
+		Ω(resource.CheckAccessToken(context.Background(), gitlabClient, source, writer, "read_api")).Should(Succeed())
+	})
+
+	It("fails when GitLab rejects the token", func() {
+		gitlabClient.GetAccessTokenReturns(nil, &resource.RequestError{Method: "GET", StatusCode: 401})
+		err := resource.CheckAccessToken(context.Background(), gitlabClient, source, writer, "api")
+		Ω(err).Should(MatchError(resource.ErrUnauthorized))
+	})
+})
@@ -0,0 +1,95 @@
+package resource
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// gitlabURLs builds the URLs of a GitLab instance, which may live under a
+// relative URL root such as https://example.com/gitlab/.
+type gitlabURLs struct {
+	// root of the instance, with a trailing slash
+	root *url.URL
+}
+
+// newGitLabURLs derives the root of the instance from the API URL, with or
+// without its /api/v4 suffix.
+func newGitLabURLs(apiURL string) (*gitlabURLs, error) {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	root := *u
+	root.Path = strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/api/v4") + "/"
+	root.RawPath = ""
+	root.RawQuery = ""
+	root.Fragment = ""
+	return &gitlabURLs{root: &root}, nil
+}
+
+// newProjectURLs derives the root of the instance from the web URL of the
+// project, which GitLab builds as the root followed by the project path.
+func newProjectURLs(project *gitlab.Project) (*gitlabURLs, error) {
+	u, err := url.Parse(project.WebURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid web_url of project '%s': %s", project.PathWithNamespace, err)
+	}
+	projectPath := "/" + strings.Trim(project.PathWithNamespace, "/")
+	webPath := strings.TrimSuffix(u.Path, "/")
+	if !strings.HasSuffix(strings.ToLower(webPath), strings.ToLower(projectPath)) {
+		return nil, fmt.Errorf("web_url '%s' of project '%s' does not end with its path", project.WebURL, project.PathWithNamespace)
+	}
+	root := *u
+	root.Path = webPath[:len(webPath)-len(projectPath)] + "/"
+	root.RawPath = ""
+	return &gitlabURLs{root: &root}, nil
+}
+
+func (u *gitlabURLs) resolve(p string) string {
+	resolved := *u.root
+	resolved.Path = u.root.Path + strings.TrimPrefix(p, "/")
+	return resolved.String()
+}
+
+// upload returns the absolute URL of a file uploaded to the project. Its
+// full_path is relative to the host, and may or may not include the relative
+// URL root depending on the GitLab version; older versions only return a URL
+// relative to the project.
+func (u *gitlabURLs) upload(project *gitlab.Project, upload *gitlab.ProjectMarkdownUploadedFile) string {
+	if upload.FullPath == "" {
+		return strings.TrimSuffix(project.WebURL, "/") + "/" + strings.TrimPrefix(upload.URL, "/")
+	}
+	if u.root.Path != "/" && strings.HasPrefix(upload.FullPath, u.root.Path) {
+		return u.resolve(strings.TrimPrefix(upload.FullPath, u.root.Path))
+	}
+	return u.resolve(upload.FullPath)
+}
+
+// downloadURL returns the URL to download a release link from. Links created
+// by former versions of this resource pointed uploads below the API root,
+// e.g. https://example.com/api/v4/group/project/uploads/..., which GitLab
+// serves from the web root (https://gitlab.com/gitlab-org/gitlab/-/issues/51447).
+// Actual API endpoints such as packages are kept as is.
+func (u *gitlabURLs) downloadURL(fileURL string) (*url.URL, error) {
+	parsed, err := url.Parse(fileURL)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(parsed.Host, u.root.Host) {
+		return parsed, nil
+	}
+	apiRoot := u.root.Path + "api/v4/"
+	if !strings.HasPrefix(parsed.Path, apiRoot) {
+		return parsed, nil
+	}
+	rest := strings.TrimPrefix(parsed.Path, apiRoot)
+	if strings.HasPrefix(rest, "projects/") || strings.HasPrefix(rest, "groups/") {
+		return parsed, nil
+	}
+	parsed.Path = u.root.Path + rest
+	parsed.RawPath = ""
+	return parsed, nil
+}
@@ -0,0 +1,203 @@
+package resource_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/onsi/gomega/ghttp"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+
+	resource "github.com/orange-cloudfoundry/gitlab-release-resource"
+	"github.com/orange-cloudfoundry/gitlab-release-resource/fakes"
+)
+
+var _ = Describe("Release link URLs", func() {
+	for _, tc := range []struct {
+		description string
+		webURL      string
+		path        string
+		upload      gitlab.ProjectMarkdownUploadedFile
+		expected    string
+	}{
+		{
+			description: "an instance at the root",
+			webURL:      "https://gitlab.example.com/group/project",
+			path:        "group/project",
+			upload:      gitlab.ProjectMarkdownUploadedFile{URL: "/uploads/abc/asset.tgz", FullPath: "/group/project/uploads/abc/asset.tgz"},
+			expected:    "https://gitlab.example.com/group/project/uploads/abc/asset.tgz",
+		},
+		{
+			description: "an instance under a relative URL root",
+			webURL:      "https://example.com/gitlab/group/project",
+			path:        "group/project",
+			upload:      gitlab.ProjectMarkdownUploadedFile{URL: "/uploads/abc/asset.tgz", FullPath: "/group/project/uploads/abc/asset.tgz"},
+			expected:    "https://example.com/gitlab/group/project/uploads/abc/asset.tgz",
+		},
+		{
+			description: "a full path including the relative URL root",
+			webURL:      "https://example.com/gitlab/group/project",
+			path:        "group/project",
+			upload:      gitlab.ProjectMarkdownUploadedFile{URL: "/uploads/abc/asset.tgz", FullPath: "/gitlab/group/project/uploads/abc/asset.tgz"},
+			expected:    "https://example.com/gitlab/group/project/uploads/abc/asset.tgz",
+		},
+		{
+			description: "a full path by project ID",
+			webURL:      "https://example.com/gitlab/group/sub/project",
+			path:        "group/sub/project",
+			upload:      gitlab.ProjectMarkdownUploadedFile{URL: "/uploads/abc/asset.tgz", FullPath: "/-/project/42/uploads/abc/asset.tgz"},
+			expected:    "https://example.com/gitlab/-/project/42/uploads/abc/asset.tgz",
+		},
+		{
+			description: "an upload without full path",
+			webURL:      "https://example.com/gitlab/group/project/",
+			path:        "group/project",
+			upload:      gitlab.ProjectMarkdownUploadedFile{URL: "/uploads/abc/asset.tgz"},
+			expected:    "https://example.com/gitlab/group/project/uploads/abc/asset.tgz",
+		},
+	} {
+		tc := tc
+		It("links uploads of "+tc.description, func() {
+			sourcesDir, err := os.MkdirTemp("", "gitlab-urls")
+			Ω(err).ShouldNot(HaveOccurred())
+			defer os.RemoveAll(sourcesDir)
+			file(filepath.Join(sourcesDir, "tag"), "v1.0.0")
+			file(filepath.Join(sourcesDir, "asset.tgz"), "asset")
+
+			gitlabClient := &fakes.FakeGitLab{}
+			gitlabClient.GetTagReturns(&gitlab.Tag{Name: "v1.0.0"}, nil)
+			gitlabClient.GetReleaseReturns(&gitlab.Release{TagName: "v1.0.0"}, nil)
+			gitlabClient.UpdateReleaseReturns(&gitlab.Release{TagName: "v1.0.0"}, nil)
+			gitlabClient.GetProjectReturns(&gitlab.Project{ID: 42, WebURL: tc.webURL, PathWithNamespace: tc.path}, nil)
+			upload := tc.upload
+			gitlabClient.UploadProjectFileReturns(&upload, nil)
+
+			_, err = resource.NewOutCommand(gitlabClient, io.Discard).Run(context.Background(), sourcesDir, resource.OutRequest{
+				Params: resource.OutParams{TagPath: "tag", Globs: []string{"*.tgz"}},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, _, spec := gitlabClient.CreateReleaseLinkArgsForCall(0)
+			Ω(spec.URL).Should(HavePrefix(tc.expected + "#sha256="))
+		})
+	}
+
+	It("rejects a web_url that does not end with the project path", func() {
+		sourcesDir, err := os.MkdirTemp("", "gitlab-urls")
+		Ω(err).ShouldNot(HaveOccurred())
+		defer os.RemoveAll(sourcesDir)
+		file(filepath.Join(sourcesDir, "tag"), "v1.0.0")
+		file(filepath.Join(sourcesDir, "asset.tgz"), "asset")
+
+		gitlabClient := &fakes.FakeGitLab{}
+		gitlabClient.GetReleaseReturns(&gitlab.Release{TagName: "v1.0.0"}, nil)
+		gitlabClient.UpdateReleaseReturns(&gitlab.Release{TagName: "v1.0.0"}, nil)
+		gitlabClient.GetProjectReturns(&gitlab.Project{WebURL: "https://example.com/other", PathWithNamespace: "group/project"}, nil)
+
+		_, err = resource.NewOutCommand(gitlabClient, io.Discard).Run(context.Background(), sourcesDir, resource.OutRequest{
+			Params: resource.OutParams{TagPath: "tag", Globs: []string{"*.tgz"}},
+		})
+		Ω(err).Should(MatchError("web_url 'https://example.com/other' of project 'group/project' does not end with its path"))
+		Ω(gitlabClient.UploadProjectFileCallCount()).Should(Equal(0))
+	})
+})
+
+var _ = Describe("Download URLs", func() {
+	var server *ghttp.Server
+	var tmpDir string
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+
+		var err error
+		tmpDir, err = os.MkdirTemp("", "gitlab-urls")
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(tmpDir)
+	})
+
+	for _, tc := range []struct {
+		description string
+		apiURL      string
+		link        string
+		expected    string
+	}{
+		{
+			description: "legacy links below the API root",
+			apiURL:      "/api/v4",
+			link:        "/api/v4/group/project//uploads/abc/asset.tgz",
+			expected:    "/group/project//uploads/abc/asset.tgz",
+		},
+		{
+			description: "legacy links below the API root of a relative URL root",
+			apiURL:      "/gitlab/api/v4/",
+			link:        "/gitlab/api/v4/group/project/uploads/abc/asset.tgz",
+			expected:    "/gitlab/group/project/uploads/abc/asset.tgz",
+		},
+		{
+			description: "legacy links with an API URL without /api/v4",
+			apiURL:      "/gitlab",
+			link:        "/gitlab/api/v4/group/project/uploads/abc/asset.tgz",
+			expected:    "/gitlab/group/project/uploads/abc/asset.tgz",
+		},
+		{
+			description: "API endpoints",
+			apiURL:      "/gitlab/api/v4",
+			link:        "/gitlab/api/v4/projects/42/packages/generic/app/1.0.0/asset.tgz",
+			expected:    "/gitlab/api/v4/projects/42/packages/generic/app/1.0.0/asset.tgz",
+		},
+		{
+			description: "web links",
+			apiURL:      "/gitlab/api/v4",
+			link:        "/gitlab/group/api/v4/uploads/abc/asset.tgz",
+			expected:    "/gitlab/group/api/v4/uploads/abc/asset.tgz",
+		},
+	} {
+		tc := tc
+		It("downloads "+tc.description, func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", tc.expected),
+					ghttp.RespondWith(200, "asset"),
+				),
+			)
+
+			client, err := resource.NewGitLabClient(resource.Source{
+				Repository:   "group/project",
+				GitLabAPIURL: server.URL() + tc.apiURL,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, err = client.DownloadProjectFile(context.Background(), server.URL()+tc.link, filepath.Join(tmpDir, "asset.tgz"), 0)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	}
+
+	It("derives the instance root from the resolved project", func() {
+		server.AppendHandlers(
+			ghttp.RespondWith(200, `{ "id": 42, "path_with_namespace": "group/project", "web_url": "`+server.URL()+`/gitlab/group/project" }`),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/gitlab/group/project/uploads/abc/asset.tgz"),
+				ghttp.RespondWith(200, "asset"),
+			),
+		)
+
+		client, err := resource.NewGitLabClient(resource.Source{
+			Repository:   "group/project",
+			GitLabAPIURL: server.URL(),
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		_, err = client.GetProject(context.Background())
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = client.DownloadProjectFile(context.Background(), server.URL()+"/gitlab/api/v4/group/project/uploads/abc/asset.tgz", filepath.Join(tmpDir, "asset.tgz"), 0)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+})
@@ -2,7 +2,9 @@ package resource
 
 import (
 	"regexp"
+	"sort"
 
+	"github.com/cppforlife/go-semi-semantic/version"
 	gitlab "gitlab.com/gitlab-org/api/client-go"
 )
 
@@ -37,3 +39,43 @@ func versionFromRelease(release *gitlab.Release) Version {
 		CommitSHA: release.Commit.ID,
 	}
 }
+
+// sortReleases keeps only the releases whose tag can be parsed as a version
+// and sorts them from older to newer by that version, release names being
+// ignored. check and the changelog both order releases with it.
+func sortReleases(releases []*gitlab.Release, vp versionParser) []*gitlab.Release {
+	sorted := []*gitlab.Release{}
+	for _, r := range releases {
+		if _, err := version.NewVersionFromString(vp.parse(r.TagName)); err != nil {
+			continue
+		}
+		sorted = append(sorted, r)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		// errors ignored since has already been filtered out above
+		first, _ := version.NewVersionFromString(vp.parse(sorted[i].TagName))
+		second, _ := version.NewVersionFromString(vp.parse(sorted[j].TagName))
+		return first.IsLt(second)
+	})
+	return sorted
+}
+
+// previousRelease returns the release preceding the given tag in version
+// order, or nil when there is none.
+func previousRelease(releases []*gitlab.Release, tag string, vp versionParser) *gitlab.Release {
+	current, err := version.NewVersionFromString(vp.parse(tag))
+	if err != nil {
+		return nil
+	}
+
+	var previous *gitlab.Release
+	for _, r := range sortReleases(releases, vp) {
+		v, _ := version.NewVersionFromString(vp.parse(r.TagName))
+		if !v.IsLt(current) {
+			break
+		}
+		previous = r
+	}
+	return previous
+}